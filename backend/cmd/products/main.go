@@ -0,0 +1,288 @@
+// Command products imports and exports the product catalogue as JSON or CSV,
+// keyed by SKU.
+//
+//	go run ./cmd/products export [-format json|csv] [-o file]
+//	go run ./cmd/products import [-format json|csv] [-dry-run] <file>
+//	go run ./cmd/products backfill-slugs
+//
+// Import creates products whose SKU is new and updates the fields that differ
+// on existing ones; fields missing from a record are left alone. Every change
+// is printed as a diff, and -dry-run stops there without writing. The changes
+// are written in one transaction, so a failed import leaves the catalogue
+// untouched. Afterwards, subscribers of updated products that are back in
+// stock get their alerts, as after an admin edit.
+//
+// backfill-slugs generates slugs for products that have none. The server does
+// the same on startup; the command covers databases migrated without
+// restarting it.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+
+	"github.com/ryangel/ryangel-backend/internal/config"
+	"github.com/ryangel/ryangel-backend/internal/database"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+	"github.com/ryangel/ryangel-backend/internal/services"
+	authsvc "github.com/ryangel/ryangel-backend/internal/services/auth"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = runExport(os.Args[2:])
+	case "import":
+		err = runImport(os.Args[2:])
+	case "backfill-slugs":
+		err = runBackfillSlugs()
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "products: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintln(os.Stderr, "  products export [-format json|csv] [-o file]")
+	fmt.Fprintln(os.Stderr, "  products import [-format json|csv] [-dry-run] <file>")
+	fmt.Fprintln(os.Stderr, "  products backfill-slugs")
+	os.Exit(2)
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "", "json or csv (default: from -o extension, else json)")
+	output := fs.String("o", "", "output file (default: stdout)")
+	fs.Parse(args)
+
+	f, err := resolveFormat(*format, *output)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	pool, _, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	repo := repository.NewProductRepository(pool)
+	products, err := repo.ListAllProducts(ctx)
+	if err != nil {
+		return err
+	}
+	components, err := repo.ListBundleComponents(ctx)
+	if err != nil {
+		return err
+	}
+	skus := make(map[int64]string, len(products))
+	for _, p := range products {
+		skus[p.ID] = p.SKU
+	}
+	records := make([]record, 0, len(products))
+	for _, p := range products {
+		r, err := recordFromProduct(p, components[p.ID], skus)
+		if err != nil {
+			return err
+		}
+		records = append(records, r)
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if f == "csv" {
+		err = writeCSV(out, records)
+	} else {
+		err = writeJSON(out, records)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d products\n", len(records))
+	return nil
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "", "json or csv (default: from file extension)")
+	dryRun := fs.Bool("dry-run", false, "print the changes without writing them")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+	path := fs.Arg(0)
+
+	f, err := resolveFormat(*format, path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var records []record
+	if f == "csv" {
+		records, err = readCSV(file)
+	} else {
+		records, err = readJSON(file)
+	}
+	if err != nil {
+		return err
+	}
+	if errs := validate(records); len(errs) > 0 {
+		return reportErrors(errs)
+	}
+
+	ctx := context.Background()
+	pool, cfg, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	repo := repository.NewProductRepository(pool)
+	existing, err := repo.ListAllProducts(ctx)
+	if err != nil {
+		return err
+	}
+	components, err := repo.ListBundleComponents(ctx)
+	if err != nil {
+		return err
+	}
+
+	changes, errs := plan(records, existing, components)
+	if len(errs) > 0 {
+		return reportErrors(errs)
+	}
+
+	var created, updated int
+	for _, ch := range changes {
+		if line := ch.String(); line != "" {
+			fmt.Println(line)
+		}
+		switch {
+		case ch.create:
+			created++
+		case len(ch.diffs) > 0:
+			updated++
+		}
+	}
+	unchanged := len(changes) - created - updated
+
+	if *dryRun {
+		fmt.Printf("dry run: %d to create, %d to update, %d unchanged\n", created, updated, unchanged)
+		return nil
+	}
+
+	err = repo.InTx(ctx, func(tx *repository.ProductRepository) error {
+		ids := make(map[string]int64, len(existing)+created)
+		for _, p := range existing {
+			ids[p.SKU] = p.ID
+		}
+		for _, ch := range changes {
+			id, err := ch.apply(ctx, tx)
+			if err != nil {
+				return fmt.Errorf("%s: %w", ch.sku, err)
+			}
+			ids[ch.sku] = id
+		}
+		for _, ch := range changes {
+			if err := ch.applyComponents(ctx, tx, ids); err != nil {
+				return fmt.Errorf("%s: components: %w", ch.sku, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w (nothing was imported)", err)
+	}
+	fmt.Printf("%d created, %d updated, %d unchanged\n", created, updated, unchanged)
+
+	authService := authsvc.NewService(repository.NewAdminRepository(pool), repository.NewClientRepository(pool), repository.NewCartRepository(pool), cfg)
+	stockAlerts := services.NewStockAlertService(pool, authService, cfg)
+	for _, ch := range changes {
+		if !ch.create && (ch.params.ChangesAvailability() || ch.components != nil) {
+			stockAlerts.NotifyRestocked(ctx, ch.productID)
+		}
+	}
+	return nil
+}
+
+func runBackfillSlugs() error {
+	ctx := context.Background()
+	pool, _, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	n, err := repository.NewProductRepository(pool).BackfillSlugs(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d products given a slug\n", n)
+	return nil
+}
+
+// resolveFormat picks json or csv from the flag or the file extension.
+func resolveFormat(format, path string) (string, error) {
+	if format == "" {
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			return "csv", nil
+		}
+		return "json", nil
+	}
+	format = strings.ToLower(format)
+	if format != "json" && format != "csv" {
+		return "", fmt.Errorf("unknown format %q", format)
+	}
+	return format, nil
+}
+
+func reportErrors(errs []error) error {
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return errors.New("validation failed, nothing was imported")
+}
+
+func connect(ctx context.Context) (*pgxpool.Pool, *config.Config, error) {
+	_ = godotenv.Load()
+
+	cfg, err := config.FromEnv()
+	if err != nil {
+		return nil, nil, fmt.Errorf("config: %w", err)
+	}
+	pool, err := database.NewPool(ctx, cfg.DatabaseURL())
+	return pool, cfg, err
+}
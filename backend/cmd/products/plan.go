@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+)
+
+// change is the planned effect of one import record.
+type change struct {
+	sku       string
+	create    bool
+	productID int64
+	params    repository.ProductParams
+	diffs     []string
+
+	// components replaces a bundle's components when non-nil.
+	components []component
+}
+
+// String renders the change as a diff line, or "" when nothing changes.
+func (c change) String() string {
+	if c.create {
+		return fmt.Sprintf("+ %s %s", c.sku, strings.Join(c.diffs, ", "))
+	}
+	if len(c.diffs) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("~ %s\n    %s", c.sku, strings.Join(c.diffs, "\n    "))
+}
+
+// plan compares records with the stored products and bundle components.
+// New SKUs must carry product_name, product_type and price; compare_at_price
+// must stay above the price the product ends up with; components must name
+// stored or imported SKUs.
+func plan(records []record, existing []models.Product, components map[int64][]models.BundleComponent) ([]change, []error) {
+	bySKU := make(map[string]models.Product, len(existing))
+	skus := make(map[int64]string, len(existing))
+	for _, p := range existing {
+		bySKU[p.SKU] = p
+		skus[p.ID] = p.SKU
+	}
+	known := make(map[string]bool, len(existing)+len(records))
+	for sku := range bySKU {
+		known[sku] = true
+	}
+	for _, r := range records {
+		known[r.SKU] = true
+	}
+
+	changes := make([]change, 0, len(records))
+	var errs []error
+	for i, r := range records {
+		var ch change
+		var err error
+		for _, c := range r.Components {
+			if !known[c.SKU] && err == nil {
+				err = fmt.Errorf("unknown component sku %q", c.SKU)
+			}
+		}
+		if err == nil {
+			if p, found := bySKU[r.SKU]; found {
+				ch, err = planUpdate(r, p, components[p.ID], skus)
+			} else {
+				ch, err = planCreate(r)
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("record %d (%s): %w", i+1, r.SKU, err))
+			continue
+		}
+		changes = append(changes, ch)
+	}
+	return changes, errs
+}
+
+func planCreate(r record) (change, error) {
+	if r.Name == nil || r.Type == nil || r.Price == nil {
+		return change{}, errors.New("new products need product_name, product_type and price")
+	}
+	if r.CompareAtPrice != nil && *r.CompareAtPrice <= *r.Price {
+		return change{}, errors.New("compare_at_price must be greater than price")
+	}
+
+	ch := change{sku: r.SKU, create: true}
+	ch.params = repository.ProductParams{
+		Description:    r.Description,
+		Hashtag:        r.Hashtag,
+		Price:          r.Price,
+		CompareAtPrice: r.CompareAtPrice,
+		Quantity:       r.Quantity,
+		IsFeatured:     r.IsFeatured,
+		IsActive:       r.IsActive,
+		SEOTitle:       r.SEOTitle,
+		SEODescription: r.SEODescription,
+	}
+	name := strings.TrimSpace(*r.Name)
+	sku := r.SKU
+	productType := models.ProductType(*r.Type)
+	ch.params.Name = &name
+	ch.params.SKU = &sku
+	ch.params.Type = &productType
+	if r.Slug != nil && *r.Slug != "" {
+		ch.params.Slug = r.Slug
+	}
+	ch.params.AvailableSizes = sizeTypes(r.AvailableSizes)
+	if r.Tags != nil {
+		tags, err := encodeTags(r.Tags)
+		if err != nil {
+			return change{}, err
+		}
+		ch.params.Tags = &tags
+	}
+
+	ch.components = r.Components
+
+	ch.diffs = []string{
+		fmt.Sprintf("%q", name),
+		string(productType),
+		fmt.Sprintf("%.2f", *r.Price),
+		"sizes [" + strings.Join(r.AvailableSizes, " ") + "]",
+	}
+	if r.Components != nil {
+		ch.diffs = append(ch.diffs, "components ["+joinComponents(r.Components)+"]")
+	}
+	return ch, nil
+}
+
+func planUpdate(r record, p models.Product, components []models.BundleComponent, skus map[int64]string) (change, error) {
+	ch := change{sku: r.SKU, productID: p.ID}
+	diff := func(field string, from, to interface{}) {
+		ch.diffs = append(ch.diffs, fmt.Sprintf("%s: %v -> %v", field, from, to))
+	}
+
+	if r.Name != nil {
+		if name := strings.TrimSpace(*r.Name); name != p.Name {
+			diff("product_name", quote(&p.Name), quote(&name))
+			ch.params.Name = &name
+		}
+	}
+	if r.Slug != nil && *r.Slug != "" && *r.Slug != p.Slug {
+		diff("slug", quote(&p.Slug), quote(r.Slug))
+		ch.params.Slug = r.Slug
+	}
+	if r.Description != nil && *r.Description != str(p.Description) {
+		diff("product_description", quote(p.Description), quote(r.Description))
+		ch.params.Description = r.Description
+	}
+	if r.Type != nil && models.ProductType(*r.Type) != p.Type {
+		productType := models.ProductType(*r.Type)
+		diff("product_type", p.Type, productType)
+		ch.params.Type = &productType
+	}
+	if r.Hashtag != nil && *r.Hashtag != str(p.Hashtag) {
+		diff("hashtag", quote(p.Hashtag), quote(r.Hashtag))
+		ch.params.Hashtag = r.Hashtag
+	}
+	if r.Price != nil && num(r.Price) != num(&p.Price) {
+		diff("price", num(&p.Price), num(r.Price))
+		ch.params.Price = r.Price
+	}
+	if r.CompareAtPrice != nil && num(r.CompareAtPrice) != num(p.CompareAtPrice) {
+		diff("compare_at_price", orNull(num(p.CompareAtPrice)), num(r.CompareAtPrice))
+		ch.params.CompareAtPrice = r.CompareAtPrice
+	}
+	if r.ClearCompareAtPrice && p.CompareAtPrice != nil {
+		diff("compare_at_price", num(p.CompareAtPrice), "null")
+		ch.params.ClearCompareAtPrice = true
+	}
+	if r.Quantity != nil && *r.Quantity != p.Quantity {
+		diff("quantity", p.Quantity, *r.Quantity)
+		ch.params.Quantity = r.Quantity
+	}
+	if r.IsFeatured != nil && *r.IsFeatured != p.IsFeatured {
+		diff("is_featured", p.IsFeatured, *r.IsFeatured)
+		ch.params.IsFeatured = r.IsFeatured
+	}
+	if r.IsActive != nil && *r.IsActive != p.IsActive {
+		diff("is_active", p.IsActive, *r.IsActive)
+		ch.params.IsActive = r.IsActive
+	}
+	if r.SEOTitle != nil && *r.SEOTitle != str(p.SEOTitle) {
+		diff("seo_title", quote(p.SEOTitle), quote(r.SEOTitle))
+		ch.params.SEOTitle = r.SEOTitle
+	}
+	if r.SEODescription != nil && *r.SEODescription != str(p.SEODescription) {
+		diff("seo_description", quote(p.SEODescription), quote(r.SEODescription))
+		ch.params.SEODescription = r.SEODescription
+	}
+
+	if r.AvailableSizes != nil {
+		current := make([]string, len(p.AvailableSizes))
+		for i, size := range p.AvailableSizes {
+			current[i] = string(size)
+		}
+		if strings.Join(current, " ") != strings.Join(r.AvailableSizes, " ") {
+			diff("available_sizes", "["+strings.Join(current, " ")+"]", "["+strings.Join(r.AvailableSizes, " ")+"]")
+			ch.params.AvailableSizes = sizeTypes(r.AvailableSizes)
+		}
+	}
+
+	if r.Tags != nil {
+		tags, err := encodeTags(r.Tags)
+		if err != nil {
+			return change{}, err
+		}
+		current := str(p.Tags)
+		if current != "" {
+			var list []string
+			if json.Unmarshal([]byte(current), &list) == nil {
+				current, _ = encodeTags(list)
+			}
+		}
+		if tags != current {
+			diff("tags", orNull(current), tags)
+			ch.params.Tags = &tags
+		}
+	}
+
+	productType := p.Type
+	if ch.params.Type != nil {
+		productType = *ch.params.Type
+	}
+	sizes := len(p.AvailableSizes)
+	if ch.params.AvailableSizes != nil {
+		sizes = len(ch.params.AvailableSizes)
+	}
+	if productType == models.ProductTypeBundle && sizes > 0 {
+		return change{}, errors.New("bundles cannot have available_sizes")
+	}
+	if r.Components != nil {
+		if productType != models.ProductTypeBundle {
+			return change{}, errors.New("only bundles have components")
+		}
+		current := make([]component, len(components))
+		for i, bc := range components {
+			current[i] = component{SKU: skus[bc.ProductID], Quantity: bc.Quantity}
+			if bc.SizeType != nil {
+				size := string(*bc.SizeType)
+				current[i].SizeType = &size
+			}
+		}
+		if joinComponents(current) != joinComponents(r.Components) {
+			diff("components", "["+joinComponents(current)+"]", "["+joinComponents(r.Components)+"]")
+			ch.components = r.Components
+		}
+	}
+
+	price := p.Price
+	if ch.params.Price != nil {
+		price = *ch.params.Price
+	}
+	compareAt := p.CompareAtPrice
+	if ch.params.CompareAtPrice != nil || ch.params.ClearCompareAtPrice {
+		compareAt = ch.params.CompareAtPrice
+	}
+	if (ch.params.Price != nil || ch.params.CompareAtPrice != nil) && compareAt != nil && *compareAt <= price {
+		return change{}, errors.New("compare_at_price must be greater than price")
+	}
+	return ch, nil
+}
+
+// apply writes the change through the product repository and returns the
+// product's ID. UpdateProduct adds and removes variants for changed sizes.
+// Components are set by applyComponents once every product exists.
+func (c change) apply(ctx context.Context, repo *repository.ProductRepository) (int64, error) {
+	if c.create {
+		return repo.CreateProduct(ctx, c.params)
+	}
+	if len(c.diffs) == 0 {
+		return c.productID, nil
+	}
+
+	if err := repo.UpdateProduct(ctx, c.productID, c.params); err != nil {
+		return 0, err
+	}
+	return c.productID, nil
+}
+
+// applyComponents sets the bundle's components, looking SKUs up in ids.
+func (c change) applyComponents(ctx context.Context, repo *repository.ProductRepository, ids map[string]int64) error {
+	if c.components == nil {
+		return nil
+	}
+	params := make([]repository.BundleComponentParams, len(c.components))
+	for i, bc := range c.components {
+		params[i] = repository.BundleComponentParams{ProductID: ids[bc.SKU], Quantity: bc.Quantity}
+		if bc.SizeType != nil {
+			size := models.SizeType(*bc.SizeType)
+			params[i].SizeType = &size
+		}
+	}
+	return repo.SetBundleComponents(ctx, ids[c.sku], params)
+}
+
+func sizeTypes(sizes []string) []models.SizeType {
+	if sizes == nil {
+		return nil
+	}
+	out := make([]models.SizeType, len(sizes))
+	for i, size := range sizes {
+		out[i] = models.SizeType(size)
+	}
+	return out
+}
+
+func encodeTags(tags []string) (string, error) {
+	encoded, err := json.Marshal(tags)
+	return string(encoded), err
+}
+
+func quote(s *string) string {
+	if s == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%q", *s)
+}
+
+func orNull(s string) string {
+	if s == "" {
+		return "null"
+	}
+	return s
+}
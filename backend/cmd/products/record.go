@@ -0,0 +1,412 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// record is one product in an import or export file. Nil fields are left
+// unchanged on import; a nil AvailableSizes, Tags or Components likewise. A
+// compare_at_price of null (JSON) or "null" (CSV) clears it.
+type record struct {
+	SKU            string   `json:"sku"`
+	Name           *string  `json:"product_name,omitempty"`
+	Slug           *string  `json:"slug,omitempty"`
+	Description    *string  `json:"product_description,omitempty"`
+	Type           *string  `json:"product_type,omitempty"`
+	Hashtag        *string  `json:"hashtag,omitempty"`
+	Price          *float64 `json:"price,omitempty"`
+	CompareAtPrice *float64 `json:"compare_at_price,omitempty"`
+	Quantity       *int     `json:"quantity,omitempty"`
+	AvailableSizes []string `json:"available_sizes,omitempty"`
+	IsFeatured     *bool    `json:"is_featured,omitempty"`
+	IsActive       *bool    `json:"is_active,omitempty"`
+	SEOTitle       *string  `json:"seo_title,omitempty"`
+	SEODescription *string  `json:"seo_description,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	// Components lists a bundle's components; only bundles take them.
+	Components []component `json:"components,omitempty"`
+
+	ClearCompareAtPrice bool `json:"-"`
+}
+
+// component is one bundle component of a record, keyed by the component's
+// product SKU. Products sold per size need a size.
+type component struct {
+	SKU      string  `json:"sku"`
+	SizeType *string `json:"size_type,omitempty"`
+	Quantity int     `json:"quantity"`
+}
+
+// String renders the component as in CSV files: sku:size:quantity, with an
+// empty size for products not sold per size.
+func (c component) String() string {
+	return fmt.Sprintf("%s:%s:%d", c.SKU, str(c.SizeType), c.Quantity)
+}
+
+func parseComponent(value string) (component, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return component{}, fmt.Errorf("component %q is not sku:size:quantity", value)
+	}
+	c := component{SKU: strings.TrimSpace(parts[0])}
+	if size := strings.TrimSpace(parts[1]); size != "" {
+		c.SizeType = &size
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+	if err != nil {
+		return component{}, fmt.Errorf("component %q: bad quantity", value)
+	}
+	c.Quantity = n
+	return c, nil
+}
+
+// UnmarshalJSON decodes a record, rejecting unknown fields and noting a
+// compare_at_price of null.
+func (r *record) UnmarshalJSON(data []byte) error {
+	type plain record
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	var raw struct {
+		CompareAtPrice json.RawMessage `json:"compare_at_price"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	r.ClearCompareAtPrice = string(raw.CompareAtPrice) == "null"
+	return nil
+}
+
+// csvColumns is the column order of exported CSV files. Imports accept any
+// subset in any order as long as sku is present.
+var csvColumns = []string{
+	"sku", "product_name", "slug", "product_description", "product_type", "hashtag",
+	"price", "compare_at_price", "quantity", "available_sizes", "is_featured", "is_active",
+	"seo_title", "seo_description", "tags", "components",
+}
+
+// csvListSep separates list values (sizes, tags, components) inside one CSV
+// cell.
+const csvListSep = "|"
+
+// recordFromProduct converts a stored product into an export record.
+// Bundle components are named by the SKUs in skus, keyed by product ID.
+func recordFromProduct(p models.Product, components []models.BundleComponent, skus map[int64]string) (record, error) {
+	r := record{
+		SKU:            p.SKU,
+		Name:           &p.Name,
+		Description:    p.Description,
+		Hashtag:        p.Hashtag,
+		Price:          &p.Price,
+		CompareAtPrice: p.CompareAtPrice,
+		Quantity:       &p.Quantity,
+		AvailableSizes: make([]string, len(p.AvailableSizes)),
+		IsFeatured:     &p.IsFeatured,
+		IsActive:       &p.IsActive,
+		SEOTitle:       p.SEOTitle,
+		SEODescription: p.SEODescription,
+	}
+	productType := string(p.Type)
+	r.Type = &productType
+	if p.Slug != "" {
+		r.Slug = &p.Slug
+	}
+	for i, size := range p.AvailableSizes {
+		r.AvailableSizes[i] = string(size)
+	}
+	for _, bc := range components {
+		c := component{SKU: skus[bc.ProductID], Quantity: bc.Quantity}
+		if bc.SizeType != nil {
+			size := string(*bc.SizeType)
+			c.SizeType = &size
+		}
+		r.Components = append(r.Components, c)
+	}
+	if p.Tags != nil && *p.Tags != "" && *p.Tags != "null" {
+		if err := json.Unmarshal([]byte(*p.Tags), &r.Tags); err != nil {
+			return r, fmt.Errorf("%s: tags are not a list of strings", p.SKU)
+		}
+	}
+	return r, nil
+}
+
+func readJSON(in io.Reader) ([]record, error) {
+	var records []record
+	dec := json.NewDecoder(in)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&records); err != nil {
+		return nil, fmt.Errorf("parse json: %w", err)
+	}
+	return records, nil
+}
+
+func writeJSON(out io.Writer, records []record) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(records)
+}
+
+// readCSV parses a CSV file with a header row. Empty cells are treated as
+// unset, so a CSV import cannot clear a field other than compare_at_price.
+func readCSV(in io.Reader) ([]record, error) {
+	cr := csv.NewReader(in)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+
+	known := make(map[string]bool, len(csvColumns))
+	for _, col := range csvColumns {
+		known[col] = true
+	}
+	hasSKU := false
+	for i, col := range header {
+		col = strings.TrimSpace(strings.TrimPrefix(col, "\ufeff"))
+		header[i] = col
+		if !known[col] {
+			return nil, fmt.Errorf("unknown csv column %q", col)
+		}
+		hasSKU = hasSKU || col == "sku"
+	}
+	if !hasSKU {
+		return nil, errors.New("csv header has no sku column")
+	}
+
+	var records []record
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", err)
+		}
+		var r record
+		for i, col := range header {
+			if err := r.setCSV(col, strings.TrimSpace(row[i])); err != nil {
+				return nil, fmt.Errorf("line %d: %s: %w", line, col, err)
+			}
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// setCSV assigns one CSV cell to the record.
+func (r *record) setCSV(col, value string) error {
+	if value == "" {
+		return nil
+	}
+	var err error
+	switch col {
+	case "sku":
+		r.SKU = value
+	case "product_name":
+		r.Name = &value
+	case "slug":
+		r.Slug = &value
+	case "product_description":
+		r.Description = &value
+	case "product_type":
+		r.Type = &value
+	case "hashtag":
+		r.Hashtag = &value
+	case "price":
+		r.Price, err = parseFloat(value)
+	case "compare_at_price":
+		if value == "null" {
+			r.ClearCompareAtPrice = true
+			return nil
+		}
+		r.CompareAtPrice, err = parseFloat(value)
+	case "quantity":
+		var n int
+		n, err = strconv.Atoi(value)
+		r.Quantity = &n
+	case "available_sizes":
+		r.AvailableSizes = splitList(value)
+	case "is_featured":
+		r.IsFeatured, err = parseBool(value)
+	case "is_active":
+		r.IsActive, err = parseBool(value)
+	case "seo_title":
+		r.SEOTitle = &value
+	case "seo_description":
+		r.SEODescription = &value
+	case "tags":
+		r.Tags = splitList(value)
+	case "components":
+		for _, item := range splitList(value) {
+			c, err := parseComponent(item)
+			if err != nil {
+				return err
+			}
+			r.Components = append(r.Components, c)
+		}
+	}
+	return err
+}
+
+func writeCSV(out io.Writer, records []record) error {
+	cw := csv.NewWriter(out)
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, r := range records {
+		row := []string{
+			r.SKU, str(r.Name), str(r.Slug), str(r.Description), str(r.Type), str(r.Hashtag),
+			num(r.Price), num(r.CompareAtPrice), intStr(r.Quantity),
+			strings.Join(r.AvailableSizes, csvListSep), boolStr(r.IsFeatured), boolStr(r.IsActive),
+			str(r.SEOTitle), str(r.SEODescription), strings.Join(r.Tags, csvListSep),
+			joinComponents(r.Components),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// validate checks each record on its own and SKUs across the file. Fields
+// needed to create a product are checked when the import is planned.
+func validate(records []record) []error {
+	var errs []error
+	seen := make(map[string]int, len(records))
+	for i := range records {
+		r := &records[i]
+		fail := func(format string, args ...interface{}) {
+			errs = append(errs, fmt.Errorf("record %d (%s): %s", i+1, r.SKU, fmt.Sprintf(format, args...)))
+		}
+
+		r.SKU = strings.TrimSpace(r.SKU)
+		if r.SKU == "" {
+			fail("sku is required")
+			continue
+		}
+		if first, dup := seen[r.SKU]; dup {
+			fail("duplicate sku, first used by record %d", first)
+		}
+		seen[r.SKU] = i + 1
+
+		if r.Name != nil && strings.TrimSpace(*r.Name) == "" {
+			fail("product_name cannot be empty")
+		}
+		if r.Type != nil && !models.ProductType(*r.Type).IsValid() {
+			fail("invalid product_type %q", *r.Type)
+		}
+		if r.Slug != nil && *r.Slug != "" && !models.IsValidSlug(*r.Slug) {
+			fail("invalid slug %q", *r.Slug)
+		}
+		if r.Price != nil && *r.Price < 0 {
+			fail("price cannot be negative")
+		}
+		if r.CompareAtPrice != nil && *r.CompareAtPrice <= 0 {
+			fail("compare_at_price must be positive")
+		}
+		if r.Quantity != nil && *r.Quantity < 0 {
+			fail("quantity cannot be negative")
+		}
+		if r.Type != nil && models.ProductType(*r.Type) == models.ProductTypeBundle && len(r.AvailableSizes) > 0 {
+			fail("bundles cannot have available_sizes")
+		}
+		if r.Type != nil && models.ProductType(*r.Type) != models.ProductTypeBundle && r.Components != nil {
+			fail("only bundles have components")
+		}
+		parts := make(map[string]bool, len(r.Components))
+		for _, c := range r.Components {
+			switch {
+			case c.SKU == "":
+				fail("component sku is required")
+			case c.SKU == r.SKU:
+				fail("a bundle cannot contain itself")
+			case c.SizeType != nil && !models.SizeType(*c.SizeType).IsValid():
+				fail("component %s: invalid size_type %q", c.SKU, *c.SizeType)
+			case c.Quantity < 1:
+				fail("component %s: quantity must be at least 1", c.SKU)
+			case parts[c.SKU+":"+str(c.SizeType)]:
+				fail("component %s listed twice", c.SKU)
+			}
+			parts[c.SKU+":"+str(c.SizeType)] = true
+		}
+		sizes := make(map[string]bool, len(r.AvailableSizes))
+		for _, size := range r.AvailableSizes {
+			if !models.SizeType(size).IsValid() {
+				fail("invalid size_type %q", size)
+			} else if sizes[size] {
+				fail("size_type %q listed twice", size)
+			}
+			sizes[size] = true
+		}
+	}
+	return errs
+}
+
+func joinComponents(components []component) string {
+	parts := make([]string, len(components))
+	for i, c := range components {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, csvListSep)
+}
+
+func splitList(value string) []string {
+	parts := strings.Split(value, csvListSep)
+	list := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+func parseFloat(value string) (*float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	return &f, err
+}
+
+func parseBool(value string) (*bool, error) {
+	b, err := strconv.ParseBool(value)
+	return &b, err
+}
+
+func str(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func num(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', 2, 64)
+}
+
+func intStr(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
+
+func boolStr(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+
+	"github.com/ryangel/ryangel-backend/internal/config"
+	"github.com/ryangel/ryangel-backend/internal/database"
+	"github.com/ryangel/ryangel-backend/internal/logger"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+	"github.com/ryangel/ryangel-backend/internal/server"
+	ebuysvc "github.com/ryangel/ryangel-backend/internal/services"
+	authsvc "github.com/ryangel/ryangel-backend/internal/services/auth"
+	"github.com/ryangel/ryangel-backend/internal/storage"
+)
+
+func main() {
+	envFile := ".env"
+	if os.Getenv("APP_ENV") == "production" {
+		envFile = ".env.prod"
+	}
+	if err := godotenv.Load(envFile); err != nil {
+		log.Printf("warning: Error loading %s: %v", envFile, err)
+	} else {
+		log.Printf("Loaded environment from %s", envFile)
+	}
+
+	cfg, err := config.FromEnv()
+	if err != nil {
+		log.Fatalf("config error: %v", err)
+	}
+
+	appLogger, err := logger.New(cfg)
+	if err != nil {
+		log.Fatalf("logger init error: %v", err)
+	}
+	defer appLogger.Sync()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := database.NewPool(ctx, cfg.DatabaseURL())
+	if err != nil {
+		appLogger.Fatal("database connection error", zap.Error(err))
+	}
+	defer pool.Close()
+
+	if n, err := repository.NewProductRepository(pool).BackfillSlugs(ctx); err != nil {
+		appLogger.Error("product slug backfill failed", zap.Error(err))
+	} else if n > 0 {
+		appLogger.Info("product slugs backfilled", zap.Int("count", n))
+	}
+
+	adminRepo := repository.NewAdminRepository(pool)
+	clientRepo := repository.NewClientRepository(pool)
+	cartRepo := repository.NewCartRepository(pool)
+	authService := authsvc.NewService(adminRepo, clientRepo, cartRepo, cfg)
+	ebuyService := ebuysvc.NewEbuyService(pool)
+	recommendationService := ebuysvc.NewRecommendationService(pool)
+
+	mediaStorage, err := storage.New(cfg, storage.Public)
+	if err != nil {
+		appLogger.Fatal("media storage init error", zap.Error(err))
+	}
+	privateStorage, err := storage.New(cfg, storage.Private)
+	if err != nil {
+		appLogger.Fatal("private storage init error", zap.Error(err))
+	}
+
+	srv := server.New(server.Options{
+		Config:      cfg,
+		DB:          pool,
+		Logger:      appLogger,
+		AuthService: authService,
+		EbuyService: ebuyService,
+
+		RecommendationService: recommendationService,
+
+		MediaStorage:   mediaStorage,
+		PrivateStorage: privateStorage,
+	})
+
+	go func() {
+		if err := srv.Run(); err != nil && err != http.ErrServerClosed {
+			appLogger.Error("server stopped", zap.Error(err))
+		}
+	}()
+
+	appLogger.Info("server listening", zap.String("addr", cfg.HTTPAddr()))
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		appLogger.Error("graceful shutdown failed", zap.Error(err))
+	} else {
+		appLogger.Info("server shutdown complete")
+	}
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// SignResource returns an HMAC signature binding a resource path to an expiry.
+func SignResource(secret, resource string, expiresAt time.Time) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(resource))
+	mac.Write([]byte{':'})
+	mac.Write([]byte(strconv.FormatInt(expiresAt.Unix(), 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyResourceSignature checks a signature produced by SignResource and
+// rejects it once the expiry has passed.
+func VerifyResourceSignature(secret, resource string, expiresUnix int64, signature string) bool {
+	if time.Now().Unix() > expiresUnix {
+		return false
+	}
+	expected := SignResource(secret, resource, time.Unix(expiresUnix, 0))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -28,7 +31,31 @@ type Config struct {
 	TwilioAuthToken  string
 	TwilioPhoneNumber string
 	SkipSMSSending   bool
+
+	// SMTP settings for outgoing email; with no SMTPHost emails are only logged.
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+
 	MediaStoragePath string
+	MediaBaseURL     string
+	StorageDriver    string
+	SiteBaseURL      string
+
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3PrivateBucket   string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3PublicURL       string
+	S3UsePathStyle    bool
+
+	PrivateStoragePath  string
+	MediaSigningSecret  string
+	SignedURLTTLMinutes int
 
 	GoogleClientID     string
 	GoogleClientSecret string
@@ -53,7 +80,26 @@ func FromEnv() (*Config, error) {
 		TwilioAuthToken:  os.Getenv("TWILIO_AUTH_TOKEN"),
 		TwilioPhoneNumber: os.Getenv("TWILIO_PHONE_NUMBER"),
 		SkipSMSSending:   getEnvAsBool("SKIP_SMS_SENDING", false),
+		SMTPHost:         os.Getenv("SMTP_HOST"),
+		SMTPPort:         getEnv("SMTP_PORT", "587"),
+		SMTPUsername:     os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:     os.Getenv("SMTP_PASSWORD"),
+		MailFrom:         getEnv("MAIL_FROM", "RyAngel <no-reply@ryangel.com>"),
 		MediaStoragePath: getEnv("MEDIA_STORAGE_PATH", "./media"),
+		MediaBaseURL:     getEnv("MEDIA_BASE_URL", "/api/media"),
+		StorageDriver:    getEnv("STORAGE_DRIVER", "local"),
+		SiteBaseURL:      getEnv("SITE_BASE_URL", "https://ryangel.com"),
+		S3Endpoint:        os.Getenv("S3_ENDPOINT"),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:          os.Getenv("S3_BUCKET"),
+		S3PrivateBucket:   os.Getenv("S3_PRIVATE_BUCKET"),
+		S3AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		S3PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+		S3UsePathStyle:    getEnvAsBool("S3_USE_PATH_STYLE", true),
+		PrivateStoragePath:  getEnv("PRIVATE_STORAGE_PATH", "./private"),
+		MediaSigningSecret:  os.Getenv("MEDIA_SIGNING_SECRET"),
+		SignedURLTTLMinutes: getEnvAsInt("SIGNED_URL_TTL_MINUTES", 15),
 		GoogleClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
 		GoogleClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
 		GoogleRedirectURL:  getEnv("GOOGLE_REDIRECT_URL", "https://ryangel.com/api/auth/google/callback"),
@@ -63,6 +109,20 @@ func FromEnv() (*Config, error) {
 		return nil, fmt.Errorf("DB_PASSWORD is required")
 	}
 
+	if cfg.MediaSigningSecret == "" {
+		// Signed URLs must verify on every instance and after restarts.
+		if strings.EqualFold(cfg.AppEnv, "production") {
+			return nil, fmt.Errorf("MEDIA_SIGNING_SECRET is required in production")
+		}
+		// In development a random secret will do; signed URLs only survive
+		// until restart.
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("generate media signing secret: %w", err)
+		}
+		cfg.MediaSigningSecret = hex.EncodeToString(secret)
+	}
+
 	return cfg, nil
 }
 
@@ -112,3 +172,8 @@ func getEnvAsBool(key string, fallback bool) bool {
 func (c *Config) TokenTTL() time.Duration {
 	return time.Duration(c.TokenTTLMinutes) * time.Minute
 }
+
+// SignedURLTTL returns how long a signed media URL remains valid.
+func (c *Config) SignedURLTTL() time.Duration {
+	return time.Duration(c.SignedURLTTLMinutes) * time.Minute
+}
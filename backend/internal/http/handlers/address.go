@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	httpmw "github.com/ryangel/ryangel-backend/internal/http/middleware"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+	authsvc "github.com/ryangel/ryangel-backend/internal/services/auth"
+)
+
+// AddressHandler serves the signed-in client's address book.
+type AddressHandler struct {
+	Repo *repository.AddressRepository
+}
+
+// Register wires the address book routes onto the router.
+func (h AddressHandler) Register(rg *gin.RouterGroup, authSvc *authsvc.Service) {
+	addresses := rg.Group("/clients/me/addresses")
+	if authSvc != nil {
+		addresses.Use(httpmw.ClientAuth(authSvc))
+	}
+	addresses.GET("", h.listAddresses)
+	addresses.POST("", h.createAddress)
+	addresses.PATCH("/:address_id", h.updateAddress)
+	addresses.DELETE("/:address_id", h.deleteAddress)
+	addresses.POST("/:address_id/set-default", h.setDefaultAddress)
+}
+
+// addressRequest is the address payload. On create, address_line1, city and
+// country are required; state and postal_code may be empty as Macau and
+// Hong Kong addresses have none.
+type addressRequest struct {
+	AddressType  *string `json:"address_type" binding:"omitempty,max=20"`
+	AddressLine1 *string `json:"address_line1" binding:"omitempty,max=255"`
+	AddressLine2 *string `json:"address_line2" binding:"omitempty,max=255"`
+	City         *string `json:"city" binding:"omitempty,max=100"`
+	State        *string `json:"state" binding:"omitempty,max=100"`
+	PostalCode   *string `json:"postal_code" binding:"omitempty,max=20"`
+	Country      *string `json:"country" binding:"omitempty,max=100"`
+	Phone        *string `json:"phone" binding:"omitempty,max=30"`
+	IsDefault    *bool   `json:"is_default"`
+}
+
+// toParams trims the fields and rejects blanking a required one.
+func (r addressRequest) toParams(create bool) (repository.AddressParams, error) {
+	trim := func(s *string) *string {
+		if s == nil {
+			return nil
+		}
+		t := strings.TrimSpace(*s)
+		return &t
+	}
+	params := repository.AddressParams{
+		AddressType:  trim(r.AddressType),
+		AddressLine1: trim(r.AddressLine1),
+		AddressLine2: trim(r.AddressLine2),
+		City:         trim(r.City),
+		State:        trim(r.State),
+		PostalCode:   trim(r.PostalCode),
+		Country:      trim(r.Country),
+		Phone:        trim(r.Phone),
+		IsDefault:    r.IsDefault,
+	}
+	required := []struct {
+		name  string
+		value *string
+	}{
+		{"address_line1", params.AddressLine1},
+		{"city", params.City},
+		{"country", params.Country},
+	}
+	for _, f := range required {
+		if (f.value == nil && create) || (f.value != nil && *f.value == "") {
+			return params, errors.New(f.name + " is required")
+		}
+	}
+	if params.AddressType != nil && *params.AddressType == "" {
+		params.AddressType = nil
+	}
+	return params, nil
+}
+
+// listAddresses handles GET /clients/me/addresses. ?is_default=true returns
+// only the default address.
+func (h AddressHandler) listAddresses(c *gin.Context) {
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return
+	}
+	defaultOnly, _ := strconv.ParseBool(c.Query("is_default"))
+
+	addresses, err := h.Repo.List(c.Request.Context(), client.ID, defaultOnly)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch addresses.", nil)
+		return
+	}
+	c.JSON(http.StatusOK, addresses)
+}
+
+// createAddress handles POST /clients/me/addresses.
+func (h AddressHandler) createAddress(c *gin.Context) {
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return
+	}
+
+	var req addressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	params, err := req.toParams(true)
+	if err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	address, err := h.Repo.Create(c.Request.Context(), client.ID, params)
+	if err != nil {
+		writeAddressError(c, err, "Failed to save address.")
+		return
+	}
+	c.JSON(http.StatusCreated, address)
+}
+
+// updateAddress handles PATCH /clients/me/addresses/{address_id}.
+func (h AddressHandler) updateAddress(c *gin.Context) {
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return
+	}
+	addressID, ok := parseAddressID(c)
+	if !ok {
+		return
+	}
+
+	var req addressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	params, err := req.toParams(false)
+	if err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	address, err := h.Repo.Update(c.Request.Context(), client.ID, addressID, params)
+	if err != nil {
+		writeAddressError(c, err, "Failed to save address.")
+		return
+	}
+	c.JSON(http.StatusOK, address)
+}
+
+// setDefaultAddress handles POST /clients/me/addresses/{address_id}/set-default.
+// Setting the current default again is a no-op.
+func (h AddressHandler) setDefaultAddress(c *gin.Context) {
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return
+	}
+	addressID, ok := parseAddressID(c)
+	if !ok {
+		return
+	}
+
+	isDefault := true
+	address, err := h.Repo.Update(c.Request.Context(), client.ID, addressID, repository.AddressParams{IsDefault: &isDefault})
+	if err != nil {
+		writeAddressError(c, err, "Failed to set default address.")
+		return
+	}
+	c.JSON(http.StatusOK, address)
+}
+
+// deleteAddress handles DELETE /clients/me/addresses/{address_id}.
+func (h AddressHandler) deleteAddress(c *gin.Context) {
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return
+	}
+	addressID, ok := parseAddressID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Repo.Delete(c.Request.Context(), client.ID, addressID); err != nil {
+		writeAddressError(c, err, "Failed to delete address.")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func parseAddressID(c *gin.Context) (int64, bool) {
+	addressID, err := strconv.ParseInt(c.Param("address_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid address ID.", nil)
+		return 0, false
+	}
+	return addressID, true
+}
+
+func writeAddressError(c *gin.Context, err error, message string) {
+	switch err {
+	case repository.ErrNotFound:
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Address not found.", nil)
+		return
+	case repository.ErrAddressInUse:
+		writeError(c, http.StatusConflict, "ADDRESS_IN_USE", "This address is used by an order that is still open.", nil)
+		return
+	}
+	// The address book lock makes this unlikely, but the partial unique
+	// index is what guarantees a single default.
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		writeError(c, http.StatusConflict, "ADDRESS_DEFAULT_CONFLICT", "Client already has default address.", nil)
+		return
+	}
+	writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", message, nil)
+}
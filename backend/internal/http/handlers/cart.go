@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
     "sort"
@@ -19,6 +21,8 @@ type CartHandler struct {
 	Repo           *repository.CartRepository
 	EbuyStoreRepo  *repository.EbuyStoreRepository
     DiscountRepo   *repository.DiscountRepository
+	ShippingRates  *repository.ShippingRateRepository
+	Addresses      *repository.AddressRepository
 }
 
 // Register wires the cart routes onto the router.
@@ -29,9 +33,9 @@ func (h CartHandler) Register(rg *gin.RouterGroup, authService interface{}) {
 		rg.POST("/cart/items", httpmw.OptionalClientAuth(authSvc), h.AddItemToCart)
 		rg.PATCH("/cart/items/:cart_item_id", httpmw.OptionalClientAuth(authSvc), h.UpdateCartItem)
 		rg.DELETE("/cart/items/:cart_item_id", httpmw.OptionalClientAuth(authSvc), h.RemoveCartItem)
+		rg.GET("/shipping/quote", httpmw.OptionalClientAuth(authSvc), h.QuoteShipping)
 		
 		// Endpoints that Require Auth
-		rg.POST("/cart/checkout", httpmw.ClientAuth(authSvc), h.Checkout)
 		rg.POST("/cart/apply-discount", httpmw.ClientAuth(authSvc), h.ApplyDiscount)
 		rg.DELETE("/cart/discount", httpmw.ClientAuth(authSvc), h.RemoveDiscount)
 	} else {
@@ -40,6 +44,7 @@ func (h CartHandler) Register(rg *gin.RouterGroup, authService interface{}) {
 		rg.POST("/cart/items", h.AddItemToCart)
 		rg.PATCH("/cart/items/:cart_item_id", h.UpdateCartItem)
 		rg.DELETE("/cart/items/:cart_item_id", h.RemoveCartItem)
+		rg.GET("/shipping/quote", h.QuoteShipping)
 	}
 }
 
@@ -120,94 +125,118 @@ func (h CartHandler) GetCart(c *gin.Context) {
 		return
 	}
 
-	// Calculate totals
-	var subtotal float64
-	for _, item := range items {
-		subtotal += item.UnitPrice * float64(item.Quantity)
+	// Shipping is quoted like GET /shipping/quote, for pickup by default
+	shippingMethod, regions, ok := h.shippingDestination(c)
+	if !ok {
+		return
+	}
+
+	quote, itemDiscountAmount, err := h.quoteShipping(c.Request.Context(), cartID, items, shippingMethod, regions)
+	if err != nil && err != repository.ErrNoShippingRate {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to quote shipping.", nil)
+		return
+	}
+
+	response := gin.H{
+		"items":                   items,
+		"shipping_method":         shippingMethod,
+		"shipping_available":      quote != nil,
+		"discount":                itemDiscountAmount,
+	}
+	if quote != nil {
+		response["subtotal"] = quote.Subtotal
+		response["discounted_subtotal"] = quote.DiscountedSubtotal
+		response["shipping_fee"] = quote.ShippingFee
+		response["discounted_shipping_fee"] = quote.DiscountedShippingFee
+		response["total"] = quote.DiscountedSubtotal + quote.DiscountedShippingFee
+	} else {
+		// No rate covers this cart; show the goods total and let checkout
+		// report SHIPPING_UNAVAILABLE.
+		subtotal, discountedSubtotal := cartSubtotals(items, itemDiscountAmount)
+		response["subtotal"] = subtotal
+		response["discounted_subtotal"] = discountedSubtotal
+		response["shipping_fee"] = nil
+		response["discounted_shipping_fee"] = nil
+		response["total"] = discountedSubtotal
 	}
 
-    // Default shipping fee
-    originalShippingFee := 5.0
-    finalShippingFee := 5.0
+	c.JSON(http.StatusOK, response)
+}
+
+// autoDiscounts applies the active auto-apply discounts to the cart items,
+// returning the item discount and whether shipping is waived.
+func (h CartHandler) autoDiscounts(ctx context.Context, items []models.CartItemResponse) (float64, bool) {
 	itemDiscountAmount := 0.0
+	freeShipping := false
+	if h.DiscountRepo == nil {
+		return itemDiscountAmount, freeShipping
+	}
 
-    // Apply active auto-apply discounts
-    if h.DiscountRepo != nil {
-        discounts, err := h.DiscountRepo.GetAutoApplyDiscounts(c.Request.Context())
-        if err == nil {
-            for _, d := range discounts {
-                // Logic for BXGY
-                if d.DiscountType == "bxgy" {
-                    // Check restrictions
-                    if d.ProductTypeRestriction != nil {
-                         restriction := *d.ProductTypeRestriction
-                         // Find applicable items
-                         var applicablePrices []float64
-                         for _, item := range items {
-                             if item.ProductType == restriction {
-                                 for k := 0; k < item.Quantity; k++ {
-                                     applicablePrices = append(applicablePrices, item.UnitPrice)
-                                 }
-                             }
-                         }
-                         
-                         if len(applicablePrices) > 0 && d.BuyQuantity != nil && d.GetQuantity != nil {
-                             buy := *d.BuyQuantity
-                             get := *d.GetQuantity
-                             groupSize := buy + get
-                             
-                             // Sort asc
-                             sort.Float64s(applicablePrices)
-                             
-                             // Calculate how many free
-                             numGroups := len(applicablePrices) / groupSize
-                             numFree := numGroups * get
-                             
-                             // Sum the cheapest `numFree` items
-                             for i := 0; i < numFree; i++ {
-                                 itemDiscountAmount += applicablePrices[i]
-                             }
+    discounts, err := h.DiscountRepo.GetAutoApplyDiscounts(ctx)
+    if err != nil {
+        fmt.Printf("Error getting discounts: %v\n", err)
+        return itemDiscountAmount, freeShipping
+    }
+    for _, d := range discounts {
+        // Logic for BXGY
+        if d.DiscountType == "bxgy" {
+            // Check restrictions
+            if d.ProductTypeRestriction != nil {
+                 restriction := *d.ProductTypeRestriction
+                 // Find applicable items
+                 var applicablePrices []float64
+                 for _, item := range items {
+                     if item.ProductType == restriction {
+                         for k := 0; k < item.Quantity; k++ {
+                             applicablePrices = append(applicablePrices, item.UnitPrice)
                          }
-                    }
-                } else if d.DiscountType == "free_shipping" {
-					faiachunCount := 0
-					for _, item := range items {
-						if item.ProductType == "faiachun" {
-							faiachunCount += item.Quantity
-						}
-					}
-					if faiachunCount >= 4 {
-						finalShippingFee = 0.0
-					}
-                }
+                     }
+                 }
+
+                 if len(applicablePrices) > 0 && d.BuyQuantity != nil && d.GetQuantity != nil {
+                     buy := *d.BuyQuantity
+                     get := *d.GetQuantity
+                     groupSize := buy + get
+
+                     // Sort asc
+                     sort.Float64s(applicablePrices)
+
+                     // Calculate how many free
+                     numGroups := len(applicablePrices) / groupSize
+                     numFree := numGroups * get
+
+                     // Sum the cheapest `numFree` items
+                     for i := 0; i < numFree; i++ {
+                         itemDiscountAmount += applicablePrices[i]
+                     }
+                 }
             }
-        } else {
-             fmt.Printf("Error getting discounts: %v\n", err)
+        } else if d.DiscountType == "free_shipping" {
+			faiachunCount := 0
+			for _, item := range items {
+				if item.ProductType == "faiachun" {
+					faiachunCount += item.Quantity
+				}
+			}
+			if faiachunCount >= 4 {
+				freeShipping = true
+			}
         }
     }
-    
-    discountedSubtotal := subtotal - itemDiscountAmount
-    if discountedSubtotal < 0 {
-        discountedSubtotal = 0
-    }
-
-    // Total calculation
-	total := discountedSubtotal + finalShippingFee
-    if total < 0 {
-        total = 0
-    }
+	return itemDiscountAmount, freeShipping
+}
 
-	response := gin.H{
-		"items":                   items,
-		"subtotal":                subtotal,
-        "discounted_subtotal":     discountedSubtotal,
-        "shipping_fee":            originalShippingFee,
-        "discounted_shipping_fee": finalShippingFee,
-		"discount":                itemDiscountAmount,
-		"total":                   total,
+// cartSubtotals returns the goods total before and after the item discount.
+func cartSubtotals(items []models.CartItemResponse, itemDiscountAmount float64) (float64, float64) {
+	var subtotal float64
+	for _, item := range items {
+		subtotal += item.UnitPrice * float64(item.Quantity)
 	}
-
-	c.JSON(http.StatusOK, response)
+	discountedSubtotal := subtotal - itemDiscountAmount
+	if discountedSubtotal < 0 {
+		discountedSubtotal = 0
+	}
+	return subtotal, discountedSubtotal
 }
 
 // AddItemToCart handles POST /cart/items.
@@ -216,6 +245,8 @@ func (h CartHandler) AddItemToCart(c *gin.Context) {
 		ProductID int64   `json:"product_id" binding:"required"`
 		SizeType  *string `json:"size_type"`
 		Quantity  int     `json:"quantity" binding:"required,min=1"`
+		// Customisations are option values keyed by option_key.
+		Customisations map[string]string `json:"customisations"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -236,11 +267,18 @@ func (h CartHandler) AddItemToCart(c *gin.Context) {
 	var sizeType *models.SizeType
 	if req.SizeType != nil {
 		st := models.SizeType(*req.SizeType)
+		if !st.IsValid() {
+			writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid size_type", nil)
+			return
+		}
 		sizeType = &st
 	}
 
-	err = h.Repo.AddItemToCart(c.Request.Context(), cartID, req.ProductID, sizeType, req.Quantity)
+	err = h.Repo.AddItemToCart(c.Request.Context(), cartID, req.ProductID, sizeType, req.Customisations, req.Quantity)
 	if err != nil {
+		if writeStockError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add item to cart"})
 		return
 	}
@@ -294,6 +332,9 @@ func (h CartHandler) UpdateCartItem(c *gin.Context) {
 
 	err = h.Repo.UpdateCartItem(c.Request.Context(), cartItemID, req.Quantity)
 	if err != nil {
+		if writeStockError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart item"})
 		return
 	}
@@ -346,72 +387,6 @@ func (h CartHandler) RemoveCartItem(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Cart item removed"})
 }
 
-// Checkout handles POST /cart/checkout.
-func (h CartHandler) Checkout(c *gin.Context) {
-	// Auth required, so client exists
-	client, _ := httpmw.ClientFromContext(c)
-	cart, err := h.Repo.GetCartByClientID(c.Request.Context(), client.ID)
-	if err != nil || cart == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No cart found"})
-		return
-	}
-
-	var req struct {
-		ShippingAddressID *int64 `json:"shipping_address_id"`
-		EbuyStoreID       *string `json:"ebuy_store_id"`
-		PaymentMethod     string `json:"payment_method" binding:"required"`
-		Notes             string `json:"notes"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Validate shipping: one of address or store
-	if (req.ShippingAddressID == nil && req.EbuyStoreID == nil) || (req.ShippingAddressID != nil && req.EbuyStoreID != nil) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either shipping_address_id or ebuy_store_id"})
-		return
-	}
-
-	// Validate ebuy_store_id if provided
-	if req.EbuyStoreID != nil {
-		store, err := h.EbuyStoreRepo.GetEbuyStoreByID(c.Request.Context(), *req.EbuyStoreID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate ebuy store"})
-			return
-		}
-		if store == nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ebuy_store_id"})
-			return
-		}
-	}
-
-	// Get cart items
-	items, err := h.Repo.GetCartItems(c.Request.Context(), cart.CartID)
-	if err != nil || len(items) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cart is empty"})
-		return
-	}
-
-	// Calculate totals (simplified, no discounts yet)
-	var subtotal float64
-	for _, item := range items {
-		subtotal += item.UnitPrice * float64(item.Quantity)
-	}
-
-	// Create order (need order repository, but for now, placeholder)
-	// This would involve creating order, order_items, clearing cart, etc.
-	// For now, just clear cart and return success
-
-	err = h.Repo.ClearCart(c.Request.Context(), cart.CartID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to checkout"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Checkout successful", "order_id": 123}) // Placeholder
-}
-
 // ApplyDiscount handles POST /cart/apply-discount.
 func (h CartHandler) ApplyDiscount(c *gin.Context) {
 	client, _ := httpmw.ClientFromContext(c)
@@ -458,6 +433,30 @@ func (h CartHandler) RemoveDiscount(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Discount removed"})
 }
 
+// writeStockError reports variant availability errors and returns true when err was one.
+func writeStockError(c *gin.Context, err error) bool {
+	var customisationErr *models.CustomisationError
+	if errors.As(err, &customisationErr) {
+		writeError(c, http.StatusBadRequest, "CUSTOMISATION_INVALID", customisationErr.Error(), gin.H{"option_key": customisationErr.Key})
+		return true
+	}
+	switch err {
+	case repository.ErrNotFound:
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Product not found", nil)
+	case repository.ErrProductUnavailable:
+		writeError(c, http.StatusBadRequest, "PRODUCT_UNAVAILABLE", "This product is not available", nil)
+	case repository.ErrVariantUnavailable:
+		writeError(c, http.StatusBadRequest, "SIZE_UNAVAILABLE", "This size is not available for the product", nil)
+	case repository.ErrInsufficientStock:
+		writeError(c, http.StatusConflict, "INVENTORY_INSUFFICIENT", "Requested quantity exceeds stock", nil)
+	case repository.ErrBundleUnavailable:
+		writeError(c, http.StatusBadRequest, "BUNDLE_UNAVAILABLE", "A product in this bundle is not available", nil)
+	default:
+		return false
+	}
+	return true
+}
+
 func isValidUUID(u string) bool {
 	if len(u) != 36 {
 		return false
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	httpmw "github.com/ryangel/ryangel-backend/internal/http/middleware"
+	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+	authsvc "github.com/ryangel/ryangel-backend/internal/services/auth"
+)
+
+// CategoryHandler handles category-related HTTP requests.
+type CategoryHandler struct {
+	Repo *repository.CategoryRepository
+}
+
+// Register wires the public category routes onto the router.
+func (h CategoryHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/categories", h.ListCategories)
+}
+
+// RegisterAdmin wires the category management routes onto the router.
+func (h CategoryHandler) RegisterAdmin(rg *gin.RouterGroup, authSvc *authsvc.Service) {
+	admin := rg.Group("/admin/categories")
+	if authSvc != nil {
+		admin.Use(httpmw.AdminAuth(authSvc))
+	}
+	admin.GET("", h.AdminListCategories)
+	admin.POST("", h.CreateCategory)
+	admin.PATCH("/:category_id", h.UpdateCategory)
+	admin.DELETE("/:category_id", h.DeleteCategory)
+}
+
+// ListCategories handles GET /categories, returning the active category tree.
+func (h CategoryHandler) ListCategories(c *gin.Context) {
+	h.listCategories(c, true)
+}
+
+// AdminListCategories handles GET /admin/categories, including inactive categories.
+func (h CategoryHandler) AdminListCategories(c *gin.Context) {
+	h.listCategories(c, false)
+}
+
+func (h CategoryHandler) listCategories(c *gin.Context, activeOnly bool) {
+	nodes, err := h.Repo.ListCategories(c.Request.Context(), activeOnly)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch categories.", nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": models.BuildCategoryTree(nodes)})
+}
+
+// categoryRequest is the payload for creating or updating a category.
+// parent_category_id may be sent as null to move a category to the top level.
+type categoryRequest struct {
+	Name        *string         `json:"category_name" binding:"omitempty,max=100"`
+	Description *string         `json:"category_description"`
+	ParentID    json.RawMessage `json:"parent_category_id"`
+	IsActive    *bool           `json:"is_active"`
+	publishWindowRequest
+}
+
+func (req categoryRequest) toParams() (repository.CategoryParams, error) {
+	params := repository.CategoryParams{
+		Description: req.Description,
+		IsActive:    req.IsActive,
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return params, errors.New("category_name cannot be empty")
+		}
+		params.Name = &name
+	}
+	if len(req.ParentID) > 0 {
+		if string(req.ParentID) == "null" {
+			params.ClearParent = true
+		} else {
+			var parentID int64
+			if err := json.Unmarshal(req.ParentID, &parentID); err != nil {
+				return params, errors.New("parent_category_id must be an integer or null")
+			}
+			params.ParentID = &parentID
+		}
+	}
+	window, err := req.toWindow()
+	if err != nil {
+		return params, err
+	}
+	params.PublishWindow = window
+	return params, nil
+}
+
+// CreateCategory handles POST /admin/categories.
+func (h CategoryHandler) CreateCategory(c *gin.Context) {
+	var req categoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	params, err := req.toParams()
+	if err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	if params.Name == nil {
+		writeValidationError(c, errors.New("category_name is required"))
+		return
+	}
+	if err := validatePublishWindow(params.PublishWindow, nil, nil); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	categoryID, err := h.Repo.CreateCategory(c.Request.Context(), params)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusBadRequest, "INVALID_PARENT", "Parent category not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create category.", nil)
+		return
+	}
+
+	category, err := h.Repo.GetCategoryByID(c.Request.Context(), categoryID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch category.", nil)
+		return
+	}
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory handles PATCH /admin/categories/{category_id}.
+func (h CategoryHandler) UpdateCategory(c *gin.Context) {
+	categoryID, err := strconv.ParseInt(c.Param("category_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid category ID.", nil)
+		return
+	}
+
+	var req categoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	params, err := req.toParams()
+	if err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	existing, err := h.Repo.GetCategoryByID(ctx, categoryID)
+	if err != nil {
+		h.writeLookupError(c, err)
+		return
+	}
+	if err := validatePublishWindow(params.PublishWindow, existing.PublishAt, existing.UnpublishAt); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	if params.ParentID != nil {
+		if _, err := h.Repo.GetCategoryByID(ctx, *params.ParentID); err != nil {
+			if err == repository.ErrNotFound {
+				writeError(c, http.StatusBadRequest, "INVALID_PARENT", "Parent category not found.", nil)
+				return
+			}
+			writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch category.", nil)
+			return
+		}
+	}
+
+	if err := h.Repo.UpdateCategory(ctx, categoryID, params); err != nil {
+		if err == repository.ErrCategoryCycle {
+			writeError(c, http.StatusUnprocessableEntity, "CATEGORY_CYCLE", "A category cannot be moved under itself or its subcategories.", nil)
+			return
+		}
+		h.writeLookupError(c, err)
+		return
+	}
+
+	category, err := h.Repo.GetCategoryByID(ctx, categoryID)
+	if err != nil {
+		h.writeLookupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory handles DELETE /admin/categories/{category_id}.
+func (h CategoryHandler) DeleteCategory(c *gin.Context) {
+	categoryID, err := strconv.ParseInt(c.Param("category_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid category ID.", nil)
+		return
+	}
+
+	if err := h.Repo.DeleteCategory(c.Request.Context(), categoryID); err != nil {
+		if err == repository.ErrCategoryHasChildren {
+			writeError(c, http.StatusConflict, "CATEGORY_HAS_CHILDREN", "Move or delete the subcategories first.", nil)
+			return
+		}
+		h.writeLookupError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h CategoryHandler) writeLookupError(c *gin.Context, err error) {
+	if err == repository.ErrNotFound {
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Category not found.", nil)
+		return
+	}
+	writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update category.", nil)
+}
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	httpmw "github.com/ryangel/ryangel-backend/internal/http/middleware"
+	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+	authsvc "github.com/ryangel/ryangel-backend/internal/services/auth"
+)
+
+// CollectionHandler serves collection landing pages and their management.
+type CollectionHandler struct {
+	Repo     *repository.CollectionRepository
+	Products *repository.ProductRepository
+}
+
+// Register wires the public collection routes onto the router.
+func (h CollectionHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/collections", h.ListCollections)
+	rg.GET("/collections/:slug", h.GetCollection)
+}
+
+// RegisterAdmin wires the collection management routes onto the router.
+func (h CollectionHandler) RegisterAdmin(rg *gin.RouterGroup, authSvc *authsvc.Service) {
+	admin := rg.Group("/admin/collections")
+	if authSvc != nil {
+		admin.Use(httpmw.AdminAuth(authSvc))
+	}
+	admin.GET("", h.AdminListCollections)
+	admin.POST("", h.CreateCollection)
+	admin.PATCH("/:collection_id", h.UpdateCollection)
+	admin.DELETE("/:collection_id", h.DeleteCollection)
+	admin.PUT("/:collection_id/products", h.SetCollectionProducts)
+}
+
+// ListCollections handles GET /collections, returning listed collections.
+func (h CollectionHandler) ListCollections(c *gin.Context) {
+	h.listCollections(c, true)
+}
+
+// AdminListCollections handles GET /admin/collections, including unlisted ones.
+func (h CollectionHandler) AdminListCollections(c *gin.Context) {
+	h.listCollections(c, false)
+}
+
+func (h CollectionHandler) listCollections(c *gin.Context, listedOnly bool) {
+	collections, err := h.Repo.List(c.Request.Context(), listedOnly)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch collections.", nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": collections})
+}
+
+// GetCollection handles GET /collections/{slug}: the collection with a page of
+// its products, in the collection's order unless another sort is asked for.
+// It takes the same query parameters as GET /products.
+func (h CollectionHandler) GetCollection(c *gin.Context) {
+	collection, err := h.Repo.GetListedBySlug(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Collection not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch collection.", nil)
+		return
+	}
+
+	filters, sort, page, pageSize, ok := parseProductListQuery(c, repository.ProductSort{Field: "collection", Order: "asc"})
+	if !ok {
+		return
+	}
+	filters.CollectionID = &collection.ID
+
+	products, total, err := h.Products.ListProducts(c.Request.Context(), filters, sort, page, pageSize)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch products.", nil)
+		return
+	}
+	if filters.Query != "" {
+		attachHighlights(products, filters.Query)
+	}
+
+	c.JSON(http.StatusOK, models.CollectionLandingResponse{
+		Collection: *collection,
+		Data:       products,
+		Meta:       paginationMeta(page, pageSize, total),
+	})
+}
+
+// collectionRequest is the payload for creating or updating a collection.
+type collectionRequest struct {
+	Name        *string `json:"collection_name" binding:"omitempty,max=255"`
+	Slug        *string `json:"slug"`
+	Description *string `json:"collection_description"`
+	SortOrder   *int    `json:"sort_order"`
+	IsActive    *bool   `json:"is_active"`
+	publishWindowRequest
+}
+
+func (req collectionRequest) toParams() (repository.CollectionParams, error) {
+	params := repository.CollectionParams{
+		Description: req.Description,
+		SortOrder:   req.SortOrder,
+		IsActive:    req.IsActive,
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return params, errors.New("collection_name cannot be empty")
+		}
+		params.Name = &name
+	}
+	if req.Slug != nil {
+		slug := strings.TrimSpace(*req.Slug)
+		if !models.IsValidSlug(slug) {
+			return params, errors.New("slug must be lowercase letters, digits and single hyphens")
+		}
+		params.Slug = &slug
+	}
+	window, err := req.toWindow()
+	if err != nil {
+		return params, err
+	}
+	params.PublishWindow = window
+	return params, nil
+}
+
+// CreateCollection handles POST /admin/collections. Without a slug, one is
+// generated from the name.
+func (h CollectionHandler) CreateCollection(c *gin.Context) {
+	var req collectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	if req.Slug != nil && strings.TrimSpace(*req.Slug) == "" {
+		req.Slug = nil
+	}
+	params, err := req.toParams()
+	if err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	if params.Name == nil {
+		writeValidationError(c, errors.New("collection_name is required"))
+		return
+	}
+	if params.Slug == nil {
+		slug := models.Slugify(*params.Name)
+		if slug == "" {
+			writeValidationError(c, errors.New("slug is required when it cannot be generated from the name"))
+			return
+		}
+		params.Slug = &slug
+	}
+	if err := validatePublishWindow(params.PublishWindow, nil, nil); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	collectionID, err := h.Repo.Create(c.Request.Context(), params)
+	if err != nil {
+		writeCollectionWriteError(c, err)
+		return
+	}
+	collection, err := h.Repo.GetByID(c.Request.Context(), collectionID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch collection.", nil)
+		return
+	}
+	c.JSON(http.StatusCreated, collection)
+}
+
+// UpdateCollection handles PATCH /admin/collections/{collection_id}.
+func (h CollectionHandler) UpdateCollection(c *gin.Context) {
+	collectionID, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	var req collectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	params, err := req.toParams()
+	if err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	existing, err := h.Repo.GetByID(ctx, collectionID)
+	if err != nil {
+		writeCollectionWriteError(c, err)
+		return
+	}
+	if err := validatePublishWindow(params.PublishWindow, existing.PublishAt, existing.UnpublishAt); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	if err := h.Repo.Update(ctx, collectionID, params); err != nil {
+		writeCollectionWriteError(c, err)
+		return
+	}
+	collection, err := h.Repo.GetByID(ctx, collectionID)
+	if err != nil {
+		writeCollectionWriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, collection)
+}
+
+// DeleteCollection handles DELETE /admin/collections/{collection_id}. The
+// products themselves are kept.
+func (h CollectionHandler) DeleteCollection(c *gin.Context) {
+	collectionID, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+	if err := h.Repo.Delete(c.Request.Context(), collectionID); err != nil {
+		writeCollectionWriteError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SetCollectionProducts handles PUT /admin/collections/{collection_id}/products.
+// Body: {"product_ids": [4, 1, 9]} replaces the products in that order.
+func (h CollectionHandler) SetCollectionProducts(c *gin.Context) {
+	collectionID, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ProductIDs []int64 `json:"product_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	seen := make(map[int64]bool, len(req.ProductIDs))
+	productIDs := make([]int64, 0, len(req.ProductIDs))
+	for _, id := range req.ProductIDs {
+		if !seen[id] {
+			seen[id] = true
+			productIDs = append(productIDs, id)
+		}
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.Repo.GetByID(ctx, collectionID); err != nil {
+		writeCollectionWriteError(c, err)
+		return
+	}
+	if err := h.Repo.SetProducts(ctx, collectionID, productIDs); err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Unknown product ID.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save collection products.", nil)
+		return
+	}
+
+	collection, err := h.Repo.GetByID(ctx, collectionID)
+	if err != nil {
+		writeCollectionWriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, collection)
+}
+
+func parseCollectionID(c *gin.Context) (int64, bool) {
+	collectionID, err := strconv.ParseInt(c.Param("collection_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid collection ID.", nil)
+		return 0, false
+	}
+	return collectionID, true
+}
+
+func writeCollectionWriteError(c *gin.Context, err error) {
+	if err == repository.ErrNotFound {
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Collection not found.", nil)
+		return
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		writeError(c, http.StatusConflict, "SLUG_EXISTS", "This slug is already in use.", nil)
+		return
+	}
+	writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save collection.", nil)
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ryangel/ryangel-backend/internal/config"
+	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+)
+
+const (
+	feedCurrency = "MOP"
+	feedBrand    = "RyAngel"
+	// maxAdditionalImages is the merchant feed limit for additional_image_link.
+	maxAdditionalImages = 10
+)
+
+// FeedHandler serves sitemap.xml and the merchant product feed.
+type FeedHandler struct {
+	Repo   *repository.FeedRepository
+	Config *config.Config
+}
+
+// Register wires the sitemap and feed routes onto the router.
+func (h FeedHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/sitemap.xml", h.Sitemap)
+	rg.GET("/feeds/products.xml", h.ProductFeedXML)
+	rg.GET("/feeds/products.tsv", h.ProductFeedTSV)
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Sitemap handles GET /sitemap.xml: the home page, every listed product and
+// every visible category, with lastmod from the latest update.
+func (h FeedHandler) Sitemap(c *gin.Context) {
+	products, err := h.Repo.ListSitemapProducts(c.Request.Context())
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to build sitemap.", nil)
+		return
+	}
+	categories, err := h.Repo.ListSitemapCategories(c.Request.Context())
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to build sitemap.", nil)
+		return
+	}
+
+	set := sitemapURLSet{
+		XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		URLs:  make([]sitemapURL, 0, 1+len(products)+len(categories)),
+	}
+	set.URLs = append(set.URLs, sitemapURL{Loc: h.siteURL("/")})
+	for _, p := range products {
+		set.URLs = append(set.URLs, sitemapURL{
+			Loc:     h.productURL(p.ID, p.Slug),
+			LastMod: p.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	for _, cat := range categories {
+		set.URLs = append(set.URLs, sitemapURL{
+			Loc:     h.siteURL("/?category_id=" + strconv.FormatInt(cat.ID, 10)),
+			LastMod: cat.LastMod.UTC().Format(time.RFC3339),
+		})
+	}
+
+	writeXML(c, set)
+}
+
+type merchantFeed struct {
+	XMLName xml.Name        `xml:"rss"`
+	Version string          `xml:"version,attr"`
+	XMLNSG  string          `xml:"xmlns:g,attr"`
+	Channel merchantChannel `xml:"channel"`
+}
+
+type merchantChannel struct {
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link"`
+	Description string         `xml:"description"`
+	Items       []merchantItem `xml:"item"`
+}
+
+// merchantItem follows the Google Merchant Center product data specification.
+type merchantItem struct {
+	ID                   string   `xml:"g:id"`
+	ItemGroupID          string   `xml:"g:item_group_id,omitempty"`
+	Title                string   `xml:"g:title"`
+	Description          string   `xml:"g:description"`
+	Link                 string   `xml:"g:link"`
+	ImageLink            string   `xml:"g:image_link,omitempty"`
+	AdditionalImageLinks []string `xml:"g:additional_image_link"`
+	Availability         string   `xml:"g:availability"`
+	Price                string   `xml:"g:price"`
+	SalePrice            string   `xml:"g:sale_price,omitempty"`
+	Brand                string   `xml:"g:brand"`
+	Condition            string   `xml:"g:condition"`
+	IdentifierExists     string   `xml:"g:identifier_exists"`
+	ProductType          string   `xml:"g:product_type"`
+	Size                 string   `xml:"g:size,omitempty"`
+}
+
+// ProductFeedXML handles GET /feeds/products.xml (RSS 2.0 merchant feed).
+func (h FeedHandler) ProductFeedXML(c *gin.Context) {
+	items, ok := h.merchantItems(c)
+	if !ok {
+		return
+	}
+
+	writeXML(c, merchantFeed{
+		Version: "2.0",
+		XMLNSG:  "http://base.google.com/ns/1.0",
+		Channel: merchantChannel{
+			Title:       feedBrand,
+			Link:        h.siteURL("/"),
+			Description: feedBrand + " products",
+			Items:       items,
+		},
+	})
+}
+
+// ProductFeedTSV handles GET /feeds/products.tsv, the same feed as tab-separated text.
+func (h FeedHandler) ProductFeedTSV(c *gin.Context) {
+	items, ok := h.merchantItems(c)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(strings.Join([]string{
+		"id", "item_group_id", "title", "description", "link", "image_link", "additional_image_link",
+		"availability", "price", "sale_price", "brand", "condition", "identifier_exists", "product_type", "size",
+	}, "\t") + "\n")
+	for _, it := range items {
+		fields := []string{
+			it.ID, it.ItemGroupID, it.Title, it.Description, it.Link, it.ImageLink,
+			strings.Join(it.AdditionalImageLinks, ","),
+			it.Availability, it.Price, it.SalePrice, it.Brand, it.Condition, it.IdentifierExists,
+			it.ProductType, it.Size,
+		}
+		for i, f := range fields {
+			fields[i] = tsvField(f)
+		}
+		buf.WriteString(strings.Join(fields, "\t") + "\n")
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Data(http.StatusOK, "text/tab-separated-values; charset=utf-8", buf.Bytes())
+}
+
+// merchantItems loads the feed items and converts them to merchant entries.
+// A compare-at price above the selling price is listed as the regular price
+// with the selling price as sale_price.
+func (h FeedHandler) merchantItems(c *gin.Context) ([]merchantItem, bool) {
+	feed, err := h.Repo.ListFeedItems(c.Request.Context())
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to build product feed.", nil)
+		return nil, false
+	}
+
+	items := make([]merchantItem, 0, len(feed))
+	for _, f := range feed {
+		item := merchantItem{
+			ID:               f.ID,
+			ItemGroupID:      f.GroupID,
+			Title:            f.Title,
+			Description:      f.Description,
+			Link:             h.productURL(f.ProductID, f.Slug),
+			Availability:     "out_of_stock",
+			Price:            feedPrice(f.Price),
+			Brand:            feedBrand,
+			Condition:        "new",
+			IdentifierExists: "no",
+			ProductType:      string(f.Type),
+		}
+		if item.Description == "" {
+			item.Description = f.Title
+		}
+		if f.Quantity > 0 {
+			item.Availability = "in_stock"
+		}
+		if f.CompareAtPrice != nil && *f.CompareAtPrice > f.Price {
+			item.Price = feedPrice(*f.CompareAtPrice)
+			item.SalePrice = feedPrice(f.Price)
+		}
+		if f.SizeType != nil {
+			item.Size = string(*f.SizeType)
+		}
+		for i, u := range f.ImageURLs {
+			switch {
+			case i == 0:
+				item.ImageLink = h.absoluteURL(u)
+			case i <= maxAdditionalImages:
+				item.AdditionalImageLinks = append(item.AdditionalImageLinks, h.absoluteURL(u))
+			}
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
+
+// siteURL joins a storefront path onto SITE_BASE_URL.
+func (h FeedHandler) siteURL(path string) string {
+	return strings.TrimRight(h.Config.SiteBaseURL, "/") + path
+}
+
+// productURL is the storefront product page, matching the frontend's
+// /product/:productId route, which takes a slug or an ID.
+func (h FeedHandler) productURL(productID int64, slug string) string {
+	return h.siteURL(models.ProductPath(productID, slug))
+}
+
+// absoluteURL turns a site-relative media URL such as /api/media/... into an
+// absolute one; URLs from S3 or a CDN are already absolute.
+func (h FeedHandler) absoluteURL(u string) string {
+	if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
+		return u
+	}
+	return h.siteURL("/" + strings.TrimLeft(u, "/"))
+}
+
+func feedPrice(amount float64) string {
+	return fmt.Sprintf("%.2f %s", amount, feedCurrency)
+}
+
+// tsvField flattens tabs and line breaks so a value stays in its column.
+func tsvField(s string) string {
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return r == '\t' || r == '\n' || r == '\r'
+	}), " ")
+}
+
+// writeXML renders v as an XML document with a one-hour public cache.
+func writeXML(c *gin.Context, v interface{}) {
+	body, err := xml.Marshal(v)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to render XML.", nil)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
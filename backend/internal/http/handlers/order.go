@@ -1,25 +1,32 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"path/filepath"
-	"time"
-	"image"
+	"strconv"
 	"image/jpeg"
 	_ "image/png" // Register PNG decoder
-	"os"
 
 	"github.com/disintegration/imaging"
 	"github.com/gin-gonic/gin"
+	"github.com/ryangel/ryangel-backend/internal/config"
 	httpmw "github.com/ryangel/ryangel-backend/internal/http/middleware"
 	"github.com/ryangel/ryangel-backend/internal/repository"
 	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/services"
+	"github.com/ryangel/ryangel-backend/internal/storage"
 	authsvc "github.com/ryangel/ryangel-backend/internal/services/auth"
 )
 
 type OrderHandler struct {
-    Orders *repository.OrderRepository
+    Orders         *repository.OrderRepository
+    Config         *config.Config
+    PrivateStorage storage.Storage
+    // StockAlerts, when set, is told about stock returned by cancellations.
+    StockAlerts    *services.StockAlertService
 }
 
 func (h OrderHandler) Register(rg *gin.RouterGroup, authSvc *authsvc.Service) {
@@ -63,6 +70,7 @@ func (h OrderHandler) adminListOrders(c *gin.Context) {
     if orders == nil {
         orders = []*models.Order{}
     }
+    attachProofURLs(h.Config, orders)
     c.JSON(http.StatusOK, orders)
 }
 
@@ -105,12 +113,48 @@ func (h OrderHandler) adminUpdateStatus(c *gin.Context) {
     // Basic validation of status enum could be here
 
     if err := h.Orders.UpdateStatus(c.Request.Context(), id, models.OrderStatus(req.Status)); err != nil {
+         if err == repository.ErrNotFound {
+             writeError(c, http.StatusNotFound, "NOT_FOUND", "Order not found", nil)
+             return
+         }
+         if err == repository.ErrInsufficientStock {
+             writeError(c, http.StatusConflict, "INVENTORY_INSUFFICIENT", "Not enough stock to reopen this cancelled order.", nil)
+             return
+         }
          writeError(c, http.StatusInternalServerError, "DB_ERROR", "Failed to update status", nil)
          return
     }
+    if models.OrderStatus(req.Status) == models.OrderStatusCancelled {
+        h.notifyRestocked(id)
+    }
     c.JSON(http.StatusOK, gin.H{"status": "updated"})
 }
 
+// notifyRestocked sends back-in-stock alerts in the background for the
+// products a cancelled order returned to stock, and so for bundles made from
+// them.
+func (h OrderHandler) notifyRestocked(orderID int64) {
+    if h.StockAlerts == nil {
+        return
+    }
+    go func() {
+        ctx := context.Background()
+        items, err := h.Orders.GetOrderItems(ctx, orderID)
+        if err != nil {
+            fmt.Printf("Error loading items of order %d for stock alerts: %v\n", orderID, err)
+            return
+        }
+        seen := make(map[int64]bool)
+        for _, item := range items {
+            if seen[item.ProductID] {
+                continue
+            }
+            seen[item.ProductID] = true
+            h.StockAlerts.NotifyRestocked(ctx, item.ProductID)
+        }
+    }()
+}
+
 func (h OrderHandler) getDashboardStats(c *gin.Context) {
 	stats, err := h.Orders.GetDashboardStats(c.Request.Context())
 	if err != nil {
@@ -129,13 +173,29 @@ func (h OrderHandler) createOrder(c *gin.Context) {
 
 	// Parse multipart form
 	ebuyStoreID := c.PostForm("ebuy_store_id")
+	shippingAddressIDStr := c.PostForm("shipping_address_id")
 	name := c.PostForm("name")
 	email := c.PostForm("email")
 	instagram := c.PostForm("instagram")
 	phone := c.PostForm("phone") // verification only
 
-	if ebuyStoreID == "" || name == "" {
-		writeError(c, http.StatusBadRequest, "INVALID_DATA", "Missing required fields (store, name)", nil)
+	// Orders are either picked up from an ebuy store or delivered to a saved
+	// address, never both (orders_shipping_address_check).
+	if (ebuyStoreID == "") == (shippingAddressIDStr == "") {
+		writeError(c, http.StatusUnprocessableEntity, "ORDER_SHIPPING_CONFLICT", "Provide either shipping_address_id or ebuy_store_id.", nil)
+		return
+	}
+	var shippingAddressID *int64
+	if shippingAddressIDStr != "" {
+		id, err := strconv.ParseInt(shippingAddressIDStr, 10, 64)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "INVALID_DATA", "Invalid shipping_address_id", nil)
+			return
+		}
+		shippingAddressID = &id
+	}
+	if name == "" {
+		writeError(c, http.StatusBadRequest, "INVALID_DATA", "Missing required fields (name)", nil)
 		return
 	}
 
@@ -156,9 +216,9 @@ func (h OrderHandler) createOrder(c *gin.Context) {
 		defer file.Close()
 
 		// Decode image
-		img, _, err := image.Decode(file)
+		img, err := decodeUpload(file)
 		if err != nil {
-			writeError(c, http.StatusBadRequest, "UPLOAD_ERROR", "Invalid image format", nil)
+			writeDecodeError(c, err)
 			return
 		}
 
@@ -167,36 +227,26 @@ func (h OrderHandler) createOrder(c *gin.Context) {
 			img = imaging.Resize(img, 1024, 0, imaging.Lanczos)
 		}
 
-		// Save to /var/www/media/uploads/proofs/
-		filename := fmt.Sprintf("%d_%d_%s", client.ID, time.Now().Unix(), "proof.jpg") // Force jpg
-		saveDir := "/var/www/media/uploads/proofs"
-		
-		if err := os.MkdirAll(saveDir, 0755); err != nil {
-			// Log error via fmt or logger if available
-			fmt.Printf("Failed to create directory %s: %v\n", saveDir, err)
-			writeError(c, http.StatusInternalServerError, "UPLOAD_ERROR", "Internal server error", nil)
+		// Compress as JPEG quality 80
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80}); err != nil {
+			writeError(c, http.StatusInternalServerError, "UPLOAD_ERROR", "Failed to encode image", nil)
 			return
 		}
 
-		fullPath := filepath.Join(saveDir, filename)
-
-		// Create file
-		out, err := os.Create(fullPath)
+		// Proofs live in private storage under an unguessable key and are
+		// served through PaymentProofHandler, never by Nginx.
+		key, err := storage.RandomKey("proofs", ".jpg")
 		if err != nil {
-			writeError(c, http.StatusInternalServerError, "UPLOAD_ERROR", "Failed to save file", nil)
+			writeError(c, http.StatusInternalServerError, "UPLOAD_ERROR", "Internal server error", nil)
 			return
 		}
-		defer out.Close()
-
-		// Compress and save as JPEG quality 80
-		if err := jpeg.Encode(out, img, &jpeg.Options{Quality: 80}); err != nil {
-			writeError(c, http.StatusInternalServerError, "UPLOAD_ERROR", "Failed to encode image", nil)
+		if err := h.PrivateStorage.Put(c.Request.Context(), key, &buf, "image/jpeg"); err != nil {
+			fmt.Printf("Failed to store payment proof %s: %v\n", key, err)
+			writeError(c, http.StatusInternalServerError, "UPLOAD_ERROR", "Failed to save file", nil)
 			return
 		}
-
-		// DB stores the path served by Nginx: /media/uploads/proofs/filename 
-		// (assuming nginx maps /media -> /var/www/media)
-		proofPath = "/media/uploads/proofs/" + filename
+		proofPath = key
 	} else if err != http.ErrMissingFile {
 		writeError(c, http.StatusBadRequest, "UPLOAD_ERROR", "Error uploading file", nil)
 		return
@@ -208,6 +258,7 @@ func (h OrderHandler) createOrder(c *gin.Context) {
 	order, err := h.Orders.CreateOrder(c.Request.Context(), repository.CreateOrderParams{
 		ClientID: client.ID,
 		EbuyStoreID: ebuyStoreID,
+		ShippingAddressID: shippingAddressID,
 		Name: name,
 		Email: email,
 		Instagram: instagram,
@@ -216,6 +267,24 @@ func (h OrderHandler) createOrder(c *gin.Context) {
 		CartID: cartID,
 	})
 	if err != nil {
+		if proofPath != "" {
+			// Don't leave an orphaned proof behind for an order that doesn't exist.
+			_ = h.PrivateStorage.Delete(c.Request.Context(), proofPath)
+		}
+		var customisationErr *models.CustomisationError
+		if err == repository.ErrProductUnavailable || err == repository.ErrVariantUnavailable || err == repository.ErrInsufficientStock || err == repository.ErrBundleUnavailable ||
+			errors.As(err, &customisationErr) {
+			writeStockError(c, err)
+			return
+		}
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Address not found.", nil)
+			return
+		}
+		if err == repository.ErrNoShippingRate {
+			writeShippingUnavailable(c)
+			return
+		}
 		writeError(c, http.StatusInternalServerError, "CREATE_ERROR", err.Error(), nil)
 		return
 	}
@@ -237,6 +306,8 @@ func (h OrderHandler) listOrders(c *gin.Context) {
 		return
 	}
 
+    attachProofURLs(h.Config, orders)
+
     var result []models.OrderWithItems
     for _, o := range orders {
         items, err := h.Orders.GetOrderItems(c.Request.Context(), o.OrderID)
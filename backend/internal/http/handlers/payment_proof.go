@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ryangel/ryangel-backend/internal/auth"
+	"github.com/ryangel/ryangel-backend/internal/config"
+	httpmw "github.com/ryangel/ryangel-backend/internal/http/middleware"
+	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+	"github.com/ryangel/ryangel-backend/internal/storage"
+	authsvc "github.com/ryangel/ryangel-backend/internal/services/auth"
+)
+
+// PaymentProofHandler serves payment proofs out of private storage.
+// Proofs contain banking details, so they are only reachable by the owning
+// client, an admin, or a short-lived signed URL.
+type PaymentProofHandler struct {
+	Proofs  *repository.PaymentProofRepository
+	Storage storage.Storage
+	Config  *config.Config
+}
+
+// Register wires the payment proof routes onto the router.
+func (h PaymentProofHandler) Register(rg *gin.RouterGroup, authSvc *authsvc.Service) {
+	rg.GET("/payment-proofs/:proof_id", h.getSignedProof)
+
+	orders := rg.Group("/orders")
+	admin := rg.Group("/admin/orders")
+	if authSvc != nil {
+		orders.Use(httpmw.ClientAuth(authSvc))
+		admin.Use(httpmw.AdminAuth(authSvc))
+	}
+	orders.GET("/:id/payment-proof", h.getClientProof)
+	admin.GET("/:id/payment-proof", h.getAdminProof)
+}
+
+// getSignedProof handles GET /payment-proofs/{proof_id}?expires=...&signature=...
+func (h PaymentProofHandler) getSignedProof(c *gin.Context) {
+	proofID, err := strconv.ParseInt(c.Param("proof_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "INVALID_ID", "Invalid proof ID", nil)
+		return
+	}
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || !auth.VerifyResourceSignature(h.Config.MediaSigningSecret, proofResource(proofID), expires, c.Query("signature")) {
+		writeError(c, http.StatusForbidden, "INVALID_SIGNATURE", "Link is invalid or has expired", nil)
+		return
+	}
+
+	proof, err := h.Proofs.GetByID(c.Request.Context(), proofID)
+	if err != nil {
+		h.writeLookupError(c, err)
+		return
+	}
+	h.serveProof(c, proof)
+}
+
+// getClientProof handles GET /orders/{id}/payment-proof for the owning client.
+func (h PaymentProofHandler) getClientProof(c *gin.Context) {
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return
+	}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "INVALID_ID", "Invalid order ID", nil)
+		return
+	}
+
+	proof, err := h.Proofs.GetLatestByOrderID(c.Request.Context(), orderID)
+	if err != nil {
+		h.writeLookupError(c, err)
+		return
+	}
+	// Respond as if missing so order IDs of other clients are not confirmed.
+	if proof.ClientID != client.ID {
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Payment proof not found", nil)
+		return
+	}
+	h.serveProof(c, proof)
+}
+
+// getAdminProof handles GET /admin/orders/{id}/payment-proof.
+func (h PaymentProofHandler) getAdminProof(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "INVALID_ID", "Invalid order ID", nil)
+		return
+	}
+
+	proof, err := h.Proofs.GetLatestByOrderID(c.Request.Context(), orderID)
+	if err != nil {
+		h.writeLookupError(c, err)
+		return
+	}
+	h.serveProof(c, proof)
+}
+
+func (h PaymentProofHandler) writeLookupError(c *gin.Context, err error) {
+	if err == repository.ErrNotFound {
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Payment proof not found", nil)
+		return
+	}
+	writeError(c, http.StatusInternalServerError, "DB_ERROR", "Failed to fetch payment proof", nil)
+}
+
+func (h PaymentProofHandler) serveProof(c *gin.Context, proof *models.PaymentProof) {
+	file, err := h.Storage.Open(c.Request.Context(), proof.ProofPath)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Payment proof not found", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "STORAGE_ERROR", "Failed to read payment proof", nil)
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(proof.ProofPath))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Cache-Control", "private, no-store")
+	c.DataFromReader(http.StatusOK, -1, contentType, file, nil)
+}
+
+func proofResource(proofID int64) string {
+	return fmt.Sprintf("payment-proofs/%d", proofID)
+}
+
+// signedProofURL renders a root-relative URL, under the /api prefix like
+// media URLs, granting temporary access to a proof.
+func signedProofURL(cfg *config.Config, proofID int64) string {
+	expiresAt := time.Now().Add(cfg.SignedURLTTL())
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("signature", auth.SignResource(cfg.MediaSigningSecret, proofResource(proofID), expiresAt))
+	return fmt.Sprintf("/api/payment-proofs/%d?%s", proofID, query.Encode())
+}
+
+// attachProofURLs replaces stored proof references with signed URLs.
+func attachProofURLs(cfg *config.Config, orders []*models.Order) {
+	for _, o := range orders {
+		if o.PaymentProofID == nil {
+			continue
+		}
+		signed := signedProofURL(cfg, *o.PaymentProofID)
+		o.PaymentProof = &signed
+	}
+}
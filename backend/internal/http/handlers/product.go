@@ -1,52 +1,138 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
 
 	"github.com/ryangel/ryangel-backend/internal/config"
+	httpmw "github.com/ryangel/ryangel-backend/internal/http/middleware"
 	"github.com/ryangel/ryangel-backend/internal/models"
 	"github.com/ryangel/ryangel-backend/internal/repository"
+	"github.com/ryangel/ryangel-backend/internal/services"
+	authsvc "github.com/ryangel/ryangel-backend/internal/services/auth"
+	"github.com/ryangel/ryangel-backend/internal/storage"
 )
 
 // ProductHandler handles product-related HTTP requests.
 type ProductHandler struct {
-	Repo   *repository.ProductRepository
-	Config *config.Config
+	Repo    *repository.ProductRepository
+	Config  *config.Config
+	Storage storage.Storage
+	// StockAlerts, when set, is told about stock changes made by admins.
+	StockAlerts *services.StockAlertService
 }
 
 // Register wires the product routes onto the router.
 func (h ProductHandler) Register(rg *gin.RouterGroup) {
 	rg.GET("/products", h.ListProducts)
+	rg.GET("/products/suggest", h.SuggestProducts)
+	rg.GET("/products/by-slug/:slug", h.GetProductBySlug)
 	rg.GET("/products/:product_id", h.GetProduct)
 	rg.GET("/products/:product_id/images", h.GetProductImages)
+	rg.GET("/products/:product_id/related", h.GetRelatedProducts)
+}
+
+// RegisterAdmin wires the admin product management routes onto the router.
+func (h ProductHandler) RegisterAdmin(rg *gin.RouterGroup, authSvc *authsvc.Service) {
+	admin := rg.Group("/admin/products")
+	if authSvc != nil {
+		admin.Use(httpmw.AdminAuth(authSvc))
+	}
+	admin.POST("", h.CreateProduct)
+	admin.GET("/:product_id", h.AdminGetProduct)
+	admin.PATCH("/:product_id", h.UpdateProduct)
+	admin.POST("/:product_id/images", h.UploadProductImage)
+	admin.PUT("/:product_id/images/order", h.ReorderProductImages)
+	admin.PATCH("/:product_id/images/:image_id", h.UpdateProductImage)
+	admin.DELETE("/:product_id/images/:image_id", h.DeleteProductImage)
+	admin.POST("/:product_id/categories", h.SetProductCategories)
+	admin.PUT("/:product_id/variants/:size_type", h.UpsertProductVariant)
+	admin.DELETE("/:product_id/variants/:size_type", h.DeleteProductVariant)
+	admin.PUT("/:product_id/components", h.SetBundleComponents)
+	admin.PUT("/:product_id/customisations", h.SetCustomisationOptions)
 }
 
 // ListProducts handles GET /products with filtering, sorting, and pagination.
 func (h ProductHandler) ListProducts(c *gin.Context) {
+	filters, sort, page, pageSize, ok := parseProductListQuery(c, repository.ProductSort{Field: "created_at", Order: "desc"})
+	if !ok {
+		return
+	}
+
+	// Fetch products
+	products, total, err := h.Repo.ListProducts(c.Request.Context(), filters, sort, page, pageSize)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch products.", nil)
+		return
+	}
+
+	if filters.Query != "" {
+		attachHighlights(products, filters.Query)
+	}
+
+	facets, err := h.Repo.ListProductFacets(c.Request.Context(), filters)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch product facets.", nil)
+		return
+	}
+
+	// Calculate total pages
+	totalPages := (total + pageSize - 1) / pageSize
+
+	response := models.ProductListResponse{
+		Data: products,
+		Meta: models.PaginationMeta{
+			Page:       page,
+			PageSize:   pageSize,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+		Facets: facets,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// parseProductListQuery reads the paging, filter and sort query parameters
+// shared by product listings. Searches default to best match first, other
+// listings to defaultSort. It writes the error response when ok is false.
+func parseProductListQuery(c *gin.Context, defaultSort repository.ProductSort) (filters repository.ProductFilters, sort repository.ProductSort, page, pageSize int, ok bool) {
 	// Parse query parameters
-	page := 1
+	page = 1
 	if pageStr := c.Query("page"); pageStr != "" {
 		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
 			page = p
 		}
 	}
 
-	pageSize := 20
+	pageSize = 20
 	if sizeStr := c.Query("page_size"); sizeStr != "" {
 		if s, err := strconv.Atoi(sizeStr); err == nil && s > 0 && s <= 100 {
 			pageSize = s
 		}
 	}
 
-	filters := repository.ProductFilters{
+	filters = repository.ProductFilters{
 		Query: c.Query("q"),
 		SKU:   c.Query("sku"),
 	}
 
+	if collectionIDStr := c.Query("collection_id"); collectionIDStr != "" {
+		if collectionID, err := strconv.ParseInt(collectionIDStr, 10, 64); err == nil {
+			filters.CollectionID = &collectionID
+		}
+	}
+
 	if categoryIDStr := c.Query("category_id"); categoryIDStr != "" {
 		if categoryID, err := strconv.ParseInt(categoryIDStr, 10, 64); err == nil {
 			filters.CategoryID = &categoryID
@@ -54,7 +140,7 @@ func (h ProductHandler) ListProducts(c *gin.Context) {
 	}
 
 	if productTypeStr := c.Query("product_type"); productTypeStr != "" {
-		if productType := models.ProductType(productTypeStr); productType == models.ProductTypeFaiachun || productType == models.ProductTypeBag {
+		if productType := models.ProductType(productTypeStr); productType.IsValid() {
 			filters.ProductType = &productType
 		}
 	}
@@ -78,7 +164,10 @@ func (h ProductHandler) ListProducts(c *gin.Context) {
 	}
 
 	// Parse sort parameter (format: field or field|-field for desc)
-	sort := repository.ProductSort{Field: "created_at", Order: "desc"}
+	sort = defaultSort
+	if filters.Query != "" {
+		sort = repository.ProductSort{Field: "relevance", Order: "desc"}
+	}
 	if sortStr := c.Query("sort"); sortStr != "" {
 		if strings.HasPrefix(sortStr, "-") {
 			sort.Field = strings.TrimPrefix(sortStr, "-")
@@ -87,41 +176,69 @@ func (h ProductHandler) ListProducts(c *gin.Context) {
 			sort.Field = sortStr
 			sort.Order = "asc"
 		}
+		if !sort.Valid() {
+			writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid sort field.", gin.H{
+				"allowed": []string{"price", "name", "created_at", "popularity", "featured", "relevance", "collection"},
+			})
+			return filters, sort, 0, 0, false
+		}
 	}
 
-	// Fetch products
-	products, total, err := h.Repo.ListProducts(c.Request.Context(), filters, sort, page, pageSize)
+	return filters, sort, page, pageSize, true
+}
+
+// GetProduct handles GET /products/{product_id}.
+func (h ProductHandler) GetProduct(c *gin.Context) {
+	productIDStr := c.Param("product_id")
+	productID, err := strconv.ParseInt(productIDStr, 10, 64)
 	if err != nil {
-		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch products.", nil)
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid product ID.", nil)
 		return
 	}
 
-	// Calculate total pages
-	totalPages := (total + pageSize - 1) / pageSize
-
-	response := models.ProductListResponse{
-		Data: products,
-		Meta: models.PaginationMeta{
-			Page:       page,
-			PageSize:   pageSize,
-			Total:      total,
-			TotalPages: totalPages,
-		},
+	product, err := h.Repo.GetProductByID(c.Request.Context(), productID)
+	if err == nil && !product.IsListed(time.Now()) {
+		err = repository.ErrNotFound
+	}
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch product.", nil)
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, product)
 }
 
-// GetProduct handles GET /products/{product_id}.
-func (h ProductHandler) GetProduct(c *gin.Context) {
-	productIDStr := c.Param("product_id")
-	productID, err := strconv.ParseInt(productIDStr, 10, 64)
+// GetProductBySlug handles GET /products/by-slug/{slug}. A previous slug of a
+// product answers with a 301 to its current slug.
+func (h ProductHandler) GetProductBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+	productID, currentSlug, err := h.Repo.ResolveProductSlug(c.Request.Context(), slug)
 	if err != nil {
-		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid product ID.", nil)
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch product.", nil)
+		return
+	}
+
+	if currentSlug != slug {
+		location := path.Join(path.Dir(c.Request.URL.Path), url.PathEscape(currentSlug))
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, location)
 		return
 	}
 
 	product, err := h.Repo.GetProductByID(c.Request.Context(), productID)
+	if err == nil && !product.IsListed(time.Now()) {
+		err = repository.ErrNotFound
+	}
 	if err != nil {
 		if err == repository.ErrNotFound {
 			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product not found.", nil)
@@ -134,15 +251,48 @@ func (h ProductHandler) GetProduct(c *gin.Context) {
 	c.JSON(http.StatusOK, product)
 }
 
+// GetRelatedProducts handles GET /products/{product_id}/related.
+// Optional ?limit= (default 8, max 20).
+func (h ProductHandler) GetRelatedProducts(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid product ID.", nil)
+		return
+	}
+
+	limit := 8
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 20 {
+			limit = l
+		}
+	}
+
+	related, err := h.Repo.GetRelatedProducts(c.Request.Context(), productID, limit)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch related products.", nil)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, related)
+}
+
 // ProductImageResponse represents the response for product images API.
 type ProductImageResponse struct {
-	URL       string `json:"url"`
-	IsPrimary bool   `json:"is_primary"`
-	AltText   string `json:"alt_text"`
-	SortOrder int    `json:"sort_order"`
+	URL          string           `json:"url"`
+	ThumbnailURL string           `json:"thumbnail_url"`
+	IsPrimary    bool             `json:"is_primary"`
+	AltText      string           `json:"alt_text"`
+	SizeType     *models.SizeType `json:"size_type"`
+	SortOrder    int              `json:"sort_order"`
 }
 
 // GetProductImages handles GET /products/{product_id}/images.
+// Optional ?size_type= narrows the list to that size plus size-agnostic images.
 func (h ProductHandler) GetProductImages(c *gin.Context) {
 	productIDStr := c.Param("product_id")
 	productID, err := strconv.ParseInt(productIDStr, 10, 64)
@@ -151,8 +301,21 @@ func (h ProductHandler) GetProductImages(c *gin.Context) {
 		return
 	}
 
-	// Check if product exists
-	_, err = h.Repo.GetProductByID(c.Request.Context(), productID)
+	var sizeType *models.SizeType
+	if sizeStr := c.Query("size_type"); sizeStr != "" {
+		st := models.SizeType(sizeStr)
+		if !st.IsValid() {
+			writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid size_type.", nil)
+			return
+		}
+		sizeType = &st
+	}
+
+	// Check if product exists and is listed
+	product, err := h.Repo.GetProductByID(c.Request.Context(), productID)
+	if err == nil && !product.IsListed(time.Now()) {
+		err = repository.ErrNotFound
+	}
 	if err != nil {
 		if err == repository.ErrNotFound {
 			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product not found.", nil)
@@ -163,7 +326,7 @@ func (h ProductHandler) GetProductImages(c *gin.Context) {
 	}
 
 	// Get product images
-	images, err := h.Repo.GetProductImages(c.Request.Context(), productID)
+	images, err := h.Repo.GetProductImages(c.Request.Context(), productID, sizeType)
 	if err != nil {
 		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch product images.", nil)
 		return
@@ -173,12 +336,390 @@ func (h ProductHandler) GetProductImages(c *gin.Context) {
 	response := make([]ProductImageResponse, len(images))
 	for i, img := range images {
 		response[i] = ProductImageResponse{
-			URL:       img.URL,
-			IsPrimary: img.IsPrimary,
-			AltText:   img.AltText,
-			SortOrder: img.SortOrder,
+			URL:          img.URL,
+			ThumbnailURL: img.ThumbnailURL,
+			IsPrimary:    img.IsPrimary,
+			AltText:      img.AltText,
+			SizeType:     img.SizeType,
+			SortOrder:    img.SortOrder,
 		}
 	}
 
 	c.JSON(http.StatusOK, response)
-}
\ No newline at end of file
+}
+
+// productRequest is the payload for admin product create/update. Fields left
+// out of a PATCH body are not modified.
+type productRequest struct {
+	Name           *string  `json:"product_name" binding:"omitempty,max=255"`
+	Slug           *string  `json:"slug"`
+	Description    *string  `json:"product_description"`
+	Type           *string  `json:"product_type"`
+	Hashtag        *string  `json:"hashtag" binding:"omitempty,max=100"`
+	SKU            *string  `json:"sku" binding:"omitempty,max=100"`
+	Price          *float64 `json:"price" binding:"omitempty,gte=0"`
+	CompareAtPrice json.RawMessage `json:"compare_at_price"` // null clears it
+	Quantity       *int     `json:"quantity" binding:"omitempty,gte=0"`
+	AvailableSizes []string `json:"available_sizes"`
+	IsFeatured     *bool    `json:"is_featured"`
+	IsActive       *bool    `json:"is_active"`
+	SEOTitle       *string  `json:"seo_title" binding:"omitempty,max=255"`
+	SEODescription *string  `json:"seo_description"`
+	Tags           []string `json:"tags"`
+	publishWindowRequest
+}
+
+// toParams validates the request and converts it into repository params.
+func (req productRequest) toParams() (repository.ProductParams, error) {
+	params := repository.ProductParams{
+		Description:    req.Description,
+		Hashtag:        req.Hashtag,
+		Price:          req.Price,
+		Quantity:       req.Quantity,
+		IsFeatured:     req.IsFeatured,
+		IsActive:       req.IsActive,
+		SEOTitle:       req.SEOTitle,
+		SEODescription: req.SEODescription,
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return params, errors.New("product_name cannot be empty")
+		}
+		params.Name = &name
+	}
+
+	// An empty slug asks for one generated from the product name.
+	if req.Slug != nil {
+		slug := strings.TrimSpace(*req.Slug)
+		if slug != "" && !models.IsValidSlug(slug) {
+			return params, fmt.Errorf("slug must be lowercase letters, digits and single hyphens, at most %d characters", models.MaxSlugLength)
+		}
+		params.Slug = &slug
+	}
+
+	if req.SKU != nil {
+		sku := strings.TrimSpace(*req.SKU)
+		if sku == "" {
+			return params, errors.New("sku cannot be empty")
+		}
+		params.SKU = &sku
+	}
+
+	if len(req.CompareAtPrice) > 0 {
+		if string(req.CompareAtPrice) == "null" {
+			params.ClearCompareAtPrice = true
+		} else {
+			var compareAt float64
+			if err := json.Unmarshal(req.CompareAtPrice, &compareAt); err != nil || compareAt <= 0 {
+				return params, errors.New("compare_at_price must be a positive number or null")
+			}
+			params.CompareAtPrice = &compareAt
+		}
+	}
+
+	if req.Type != nil {
+		productType := models.ProductType(*req.Type)
+		if !productType.IsValid() {
+			return params, fmt.Errorf("invalid product_type %q", *req.Type)
+		}
+		params.Type = &productType
+	}
+
+	if req.AvailableSizes != nil {
+		params.AvailableSizes = []models.SizeType{}
+		seen := make(map[models.SizeType]bool)
+		for _, raw := range req.AvailableSizes {
+			size := models.SizeType(raw)
+			if !size.IsValid() {
+				return params, fmt.Errorf("invalid size_type %q", raw)
+			}
+			if !seen[size] {
+				seen[size] = true
+				params.AvailableSizes = append(params.AvailableSizes, size)
+			}
+		}
+	}
+
+	if req.Tags != nil {
+		encoded, err := json.Marshal(req.Tags)
+		if err != nil {
+			return params, err
+		}
+		tags := string(encoded)
+		params.Tags = &tags
+	}
+
+	window, err := req.toWindow()
+	if err != nil {
+		return params, err
+	}
+	params.PublishWindow = window
+
+	return params, nil
+}
+
+// validateCompareAtPrice rejects a compare-at price that is not above the selling price.
+func validateCompareAtPrice(price float64, compareAt *float64) error {
+	if compareAt != nil && *compareAt <= price {
+		return errors.New("compare_at_price must be greater than price")
+	}
+	return nil
+}
+
+// publishWindowRequest holds the publish_at and unpublish_at fields of admin
+// payloads. Times are RFC 3339; null clears a date.
+type publishWindowRequest struct {
+	PublishAt   json.RawMessage `json:"publish_at"`
+	UnpublishAt json.RawMessage `json:"unpublish_at"`
+}
+
+func (req publishWindowRequest) toWindow() (repository.PublishWindow, error) {
+	var window repository.PublishWindow
+	var err error
+	if window.PublishAt, window.ClearPublishAt, err = parseNullableTime(req.PublishAt, "publish_at"); err != nil {
+		return window, err
+	}
+	if window.UnpublishAt, window.ClearUnpublishAt, err = parseNullableTime(req.UnpublishAt, "unpublish_at"); err != nil {
+		return window, err
+	}
+	return window, nil
+}
+
+// parseNullableTime decodes an optional JSON time, reporting null separately.
+func parseNullableTime(raw json.RawMessage, field string) (*time.Time, bool, error) {
+	if len(raw) == 0 {
+		return nil, false, nil
+	}
+	if string(raw) == "null" {
+		return nil, true, nil
+	}
+	var t time.Time
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return nil, false, fmt.Errorf("%s must be an RFC 3339 time or null", field)
+	}
+	return &t, false, nil
+}
+
+// validatePublishWindow rejects an unpublish_at that is not after publish_at
+// once window is applied to the current dates.
+func validatePublishWindow(window repository.PublishWindow, publishAt, unpublishAt *time.Time) error {
+	if window.ClearPublishAt {
+		publishAt = nil
+	} else if window.PublishAt != nil {
+		publishAt = window.PublishAt
+	}
+	if window.ClearUnpublishAt {
+		unpublishAt = nil
+	} else if window.UnpublishAt != nil {
+		unpublishAt = window.UnpublishAt
+	}
+	if publishAt != nil && unpublishAt != nil && !unpublishAt.After(*publishAt) {
+		return errors.New("unpublish_at must be after publish_at")
+	}
+	return nil
+}
+
+// AdminGetProduct handles GET /admin/products/{product_id}. Unlike the public
+// lookup it also returns inactive and unpublished products.
+func (h ProductHandler) AdminGetProduct(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid product ID.", nil)
+		return
+	}
+
+	product, err := h.Repo.GetProductByID(c.Request.Context(), productID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch product.", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewAdminProduct(product))
+}
+
+// CreateProduct handles POST /admin/products.
+func (h ProductHandler) CreateProduct(c *gin.Context) {
+	admin, ok := httpmw.AdminFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "AUTH_INVALID_CREDENTIALS", "Missing authentication context.", nil)
+		return
+	}
+
+	var req productRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	if req.Name == nil || req.Type == nil || req.SKU == nil || req.Price == nil {
+		writeValidationError(c, errors.New("product_name, product_type, sku and price are required"))
+		return
+	}
+
+	params, err := req.toParams()
+	if err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	if err := validateCompareAtPrice(*params.Price, params.CompareAtPrice); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	if err := validatePublishWindow(params.PublishWindow, nil, nil); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	params.CreatedBy = &admin.ID
+
+	productID, err := h.Repo.CreateProduct(c.Request.Context(), params)
+	if err != nil {
+		writeProductWriteError(c, err)
+		return
+	}
+
+	product, err := h.Repo.GetProductByID(c.Request.Context(), productID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch product.", nil)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.NewAdminProduct(product))
+}
+
+// UpdateProduct handles PATCH /admin/products/{product_id}.
+func (h ProductHandler) UpdateProduct(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid product ID.", nil)
+		return
+	}
+
+	var req productRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	params, err := req.toParams()
+	if err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	existing, err := h.Repo.GetProductByID(c.Request.Context(), productID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch product.", nil)
+		return
+	}
+
+	// Validate against the values the product will have after the update.
+	price := existing.Price
+	if params.Price != nil {
+		price = *params.Price
+	}
+	compareAt := existing.ListedCompareAtPrice
+	if params.CompareAtPrice != nil || params.ClearCompareAtPrice {
+		compareAt = params.CompareAtPrice
+	}
+	if err := validateCompareAtPrice(price, compareAt); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	if err := validatePublishWindow(params.PublishWindow, existing.PublishAt, existing.UnpublishAt); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	if err := h.Repo.UpdateProduct(c.Request.Context(), productID, params); err != nil {
+		writeProductWriteError(c, err)
+		return
+	}
+	if params.ChangesAvailability() {
+		h.notifyRestocked(productID)
+	}
+
+	product, err := h.Repo.GetProductByID(c.Request.Context(), productID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch product.", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewAdminProduct(product))
+}
+
+// SetProductCategories handles POST /admin/products/{product_id}/categories.
+// Body: {"category_ids": [1, 4]} replaces the product's category mapping.
+func (h ProductHandler) SetProductCategories(c *gin.Context) {
+	productID, ok := h.parseAdminProductID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		CategoryIDs []int64 `json:"category_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	seen := make(map[int64]bool, len(req.CategoryIDs))
+	categoryIDs := make([]int64, 0, len(req.CategoryIDs))
+	for _, id := range req.CategoryIDs {
+		if !seen[id] {
+			seen[id] = true
+			categoryIDs = append(categoryIDs, id)
+		}
+	}
+
+	if err := h.Repo.SetProductCategories(c.Request.Context(), productID, categoryIDs); err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Unknown category ID.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save product categories.", nil)
+		return
+	}
+
+	product, err := h.Repo.GetProductByID(c.Request.Context(), productID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch product.", nil)
+		return
+	}
+	c.JSON(http.StatusOK, models.NewAdminProduct(product))
+}
+
+func writeProductWriteError(c *gin.Context, err error) {
+	if err == repository.ErrNotFound {
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Product not found.", nil)
+		return
+	}
+	if err == repository.ErrBundleSized {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Bundles cannot have available_sizes.", nil)
+		return
+	}
+	if err == repository.ErrNestedBundle {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "A product used in a bundle cannot become a bundle.", nil)
+		return
+	}
+	var pgErr *pgconn.PgError
+	if err == repository.ErrSlugTaken || (errors.As(err, &pgErr) && pgErr.ConstraintName == "idx_products_slug") {
+		writeError(c, http.StatusConflict, "SLUG_EXISTS", "This slug is already in use.", nil)
+		return
+	}
+	if errors.As(err, &pgErr) && pgErr.ConstraintName == "products_sku_key" {
+		writeError(c, http.StatusConflict, "SKU_EXISTS", "This SKU is already in use.", nil)
+		return
+	}
+	writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save product.", nil)
+}
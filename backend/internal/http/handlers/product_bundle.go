@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+)
+
+type bundleComponentRequest struct {
+	ProductID int64   `json:"product_id" binding:"required"`
+	SizeType  *string `json:"size_type"`
+	Quantity  int     `json:"quantity" binding:"required,min=1"`
+}
+
+// SetBundleComponents handles PUT /admin/products/{product_id}/components.
+// Body: {"components": [{"product_id": 3, "size_type": "square", "quantity": 2}]}
+// replaces the bundle's components, in the order given.
+func (h ProductHandler) SetBundleComponents(c *gin.Context) {
+	productID, ok := h.parseAdminProductID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Components []bundleComponentRequest `json:"components" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	type componentKey struct {
+		productID int64
+		sizeType  models.SizeType
+	}
+	seen := make(map[componentKey]bool, len(req.Components))
+	components := make([]repository.BundleComponentParams, 0, len(req.Components))
+	for _, rc := range req.Components {
+		component := repository.BundleComponentParams{ProductID: rc.ProductID, Quantity: rc.Quantity}
+		key := componentKey{productID: rc.ProductID}
+		if rc.SizeType != nil {
+			sizeType := models.SizeType(*rc.SizeType)
+			if !sizeType.IsValid() {
+				writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid size_type.", nil)
+				return
+			}
+			component.SizeType = &sizeType
+			key.sizeType = sizeType
+		}
+		if seen[key] {
+			writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Each product size may only be listed once.", nil)
+			return
+		}
+		seen[key] = true
+		components = append(components, component)
+	}
+
+	if err := h.Repo.SetBundleComponents(c.Request.Context(), productID, components); err != nil {
+		switch err {
+		case repository.ErrNotBundle:
+			writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Only bundle products have components.", nil)
+		case repository.ErrNestedBundle:
+			writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "A bundle cannot contain another bundle.", nil)
+		case repository.ErrNotFound:
+			writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Unknown component product ID.", nil)
+		case repository.ErrVariantUnavailable:
+			writeError(c, http.StatusBadRequest, "SIZE_UNAVAILABLE", "Components sold per size need one of their sizes; others take none.", nil)
+		default:
+			writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save bundle components.", nil)
+		}
+		return
+	}
+	h.notifyRestocked(productID)
+
+	product, err := h.Repo.GetProductByID(c.Request.Context(), productID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch product.", nil)
+		return
+	}
+	c.JSON(http.StatusOK, models.NewAdminProduct(product))
+}
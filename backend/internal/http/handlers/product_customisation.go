@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+)
+
+// maxCustomisationLength caps the max_length of text options.
+const maxCustomisationLength = 500
+
+type customisationOptionRequest struct {
+	Key        string   `json:"option_key" binding:"required,max=50"`
+	Label      string   `json:"label" binding:"required,max=100"`
+	Type       string   `json:"option_type" binding:"required"`
+	MaxLength  *int     `json:"max_length"`
+	Choices    []string `json:"choices"`
+	IsRequired bool     `json:"is_required"`
+	Surcharge  float64  `json:"surcharge" binding:"gte=0"`
+}
+
+// toOption validates the request and converts it to an option.
+func (r customisationOptionRequest) toOption() (models.CustomisationOption, error) {
+	option := models.CustomisationOption{
+		Key:        strings.TrimSpace(r.Key),
+		Label:      strings.TrimSpace(r.Label),
+		Type:       models.CustomisationType(r.Type),
+		IsRequired: r.IsRequired,
+		Surcharge:  r.Surcharge,
+		Choices:    []string{},
+	}
+	if !models.IsValidSlug(option.Key) {
+		return option, fmt.Errorf("option_key %q must be lowercase letters, digits and single hyphens", r.Key)
+	}
+	if option.Label == "" {
+		return option, fmt.Errorf("label of %q cannot be empty", option.Key)
+	}
+
+	switch option.Type {
+	case models.CustomisationTypeText:
+		if r.MaxLength == nil || *r.MaxLength < 1 || *r.MaxLength > maxCustomisationLength {
+			return option, fmt.Errorf("text option %q needs a max_length between 1 and %d", option.Key, maxCustomisationLength)
+		}
+		option.MaxLength = r.MaxLength
+	case models.CustomisationTypeChoice:
+		seen := make(map[string]bool, len(r.Choices))
+		for _, raw := range r.Choices {
+			choice := strings.TrimSpace(raw)
+			if choice == "" || seen[choice] {
+				continue
+			}
+			seen[choice] = true
+			option.Choices = append(option.Choices, choice)
+		}
+		if len(option.Choices) == 0 {
+			return option, fmt.Errorf("choice option %q needs at least one choice", option.Key)
+		}
+	default:
+		return option, fmt.Errorf("invalid option_type %q", r.Type)
+	}
+	return option, nil
+}
+
+// SetCustomisationOptions handles PUT /admin/products/{product_id}/customisations.
+// Body: {"options": [{"option_key": "wording", "label": "Wording", "option_type": "text", "max_length": 8}]}
+// replaces the product's options, in the order given.
+func (h ProductHandler) SetCustomisationOptions(c *gin.Context) {
+	productID, ok := h.parseAdminProductID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Options []customisationOptionRequest `json:"options" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	seen := make(map[string]bool, len(req.Options))
+	options := make([]models.CustomisationOption, 0, len(req.Options))
+	for _, ro := range req.Options {
+		option, err := ro.toOption()
+		if err != nil {
+			writeValidationError(c, err)
+			return
+		}
+		if seen[option.Key] {
+			writeValidationError(c, errors.New("each option_key may only be listed once"))
+			return
+		}
+		seen[option.Key] = true
+		options = append(options, option)
+	}
+
+	if err := h.Repo.SetCustomisationOptions(c.Request.Context(), productID, options); err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save customisation options.", nil)
+		return
+	}
+
+	product, err := h.Repo.GetProductByID(c.Request.Context(), productID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch product.", nil)
+		return
+	}
+	c.JSON(http.StatusOK, models.NewAdminProduct(product))
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // Register PNG decoder
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/gin-gonic/gin"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+	"github.com/ryangel/ryangel-backend/internal/storage"
+)
+
+// Rendition sizes mirror scripts/resize_images_v2.py so uploaded images match
+// the ones produced offline.
+const (
+	productImageWidth     = 600
+	productThumbnailWidth = 200
+	productImageQuality   = 85
+	maxProductImageBytes  = 10 * 1024 * 1024
+)
+
+// maxUploadPixels caps the dimensions of uploaded images. A small compressed
+// file can declare a huge image, and decoding allocates every pixel, so the
+// size is read from the header first.
+const maxUploadPixels = 40 * 1000 * 1000
+
+var errImageTooLarge = errors.New("image dimensions too large")
+
+// decodeUpload decodes an uploaded image once its header shows it has at
+// most maxUploadPixels pixels; larger ones give errImageTooLarge.
+func decodeUpload(file multipart.File) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxUploadPixels {
+		return nil, errImageTooLarge
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(file)
+	return img, err
+}
+
+// writeDecodeError answers an upload that decodeUpload rejected.
+func writeDecodeError(c *gin.Context, err error) {
+	if err == errImageTooLarge {
+		writeError(c, http.StatusBadRequest, "UPLOAD_ERROR", "Image dimensions too large (max 40 megapixels).", nil)
+		return
+	}
+	writeError(c, http.StatusBadRequest, "UPLOAD_ERROR", "Invalid image format.", nil)
+}
+
+// UploadProductImage handles POST /admin/products/{product_id}/images.
+// Accepts multipart form: file, alt_text, size_type, sort_order, is_primary.
+func (h ProductHandler) UploadProductImage(c *gin.Context) {
+	productID, ok := h.parseAdminProductID(c)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "UPLOAD_ERROR", "Missing image file.", nil)
+		return
+	}
+	if fileHeader.Size > maxProductImageBytes {
+		writeError(c, http.StatusBadRequest, "UPLOAD_ERROR", "File too large (max 10MB).", nil)
+		return
+	}
+
+	img := models.ProductImage{
+		ProductID: productID,
+		AltText:   c.PostForm("alt_text"),
+	}
+
+	if sizeStr := c.PostForm("size_type"); sizeStr != "" {
+		sizeType := models.SizeType(sizeStr)
+		if !sizeType.IsValid() {
+			writeValidationError(c, fmt.Errorf("invalid size_type %q", sizeStr))
+			return
+		}
+		img.SizeType = &sizeType
+	}
+
+	var sortOrder *int
+	if sortStr := c.PostForm("sort_order"); sortStr != "" {
+		parsed, err := strconv.Atoi(sortStr)
+		if err != nil || parsed < 0 {
+			writeValidationError(c, fmt.Errorf("invalid sort_order %q", sortStr))
+			return
+		}
+		sortOrder = &parsed
+	}
+
+	if primaryStr := c.PostForm("is_primary"); primaryStr != "" {
+		isPrimary, err := strconv.ParseBool(primaryStr)
+		if err != nil {
+			writeValidationError(c, fmt.Errorf("invalid is_primary %q", primaryStr))
+			return
+		}
+		img.IsPrimary = isPrimary
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "UPLOAD_ERROR", "Failed to open file.", nil)
+		return
+	}
+	defer file.Close()
+
+	source, err := decodeUpload(file)
+	if err != nil {
+		writeDecodeError(c, err)
+		return
+	}
+
+	key, err := storage.RandomKey(fmt.Sprintf("products/%d", productID), ".jpg")
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "UPLOAD_ERROR", "Internal server error.", nil)
+		return
+	}
+	thumbKey := strings.TrimSuffix(key, ".jpg") + "-sm.jpg"
+
+	ctx := c.Request.Context()
+	if err := h.putRendition(ctx, key, source, productImageWidth); err != nil {
+		fmt.Printf("Failed to store product image %s: %v\n", key, err)
+		writeError(c, http.StatusInternalServerError, "UPLOAD_ERROR", "Failed to save image.", nil)
+		return
+	}
+	if err := h.putRendition(ctx, thumbKey, source, productThumbnailWidth); err != nil {
+		fmt.Printf("Failed to store product thumbnail %s: %v\n", thumbKey, err)
+		_ = h.Storage.Delete(ctx, key)
+		writeError(c, http.StatusInternalServerError, "UPLOAD_ERROR", "Failed to save image.", nil)
+		return
+	}
+
+	img.URL = h.Storage.URL(key)
+	img.ThumbnailURL = h.Storage.URL(thumbKey)
+
+	if err := h.Repo.CreateProductImage(ctx, &img, sortOrder); err != nil {
+		_ = h.Storage.Delete(ctx, key)
+		_ = h.Storage.Delete(ctx, thumbKey)
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save product image.", nil)
+		return
+	}
+
+	c.JSON(http.StatusCreated, img)
+}
+
+type updateProductImageRequest struct {
+	AltText   *string `json:"alt_text" binding:"omitempty,max=255"`
+	SizeType  *string `json:"size_type"`
+	SortOrder *int    `json:"sort_order" binding:"omitempty,gte=0"`
+	IsPrimary *bool   `json:"is_primary"`
+}
+
+// UpdateProductImage handles PATCH /admin/products/{product_id}/images/{image_id}.
+func (h ProductHandler) UpdateProductImage(c *gin.Context) {
+	productID, ok := h.parseAdminProductID(c)
+	if !ok {
+		return
+	}
+	imageID, err := strconv.ParseInt(c.Param("image_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid image ID.", nil)
+		return
+	}
+
+	var req updateProductImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	params := repository.ProductImageParams{
+		AltText:   req.AltText,
+		SortOrder: req.SortOrder,
+		IsPrimary: req.IsPrimary,
+	}
+	if req.SizeType != nil {
+		sizeType := models.SizeType(*req.SizeType)
+		if !sizeType.IsValid() {
+			writeValidationError(c, fmt.Errorf("invalid size_type %q", *req.SizeType))
+			return
+		}
+		params.SizeType = &sizeType
+	}
+
+	img, err := h.Repo.UpdateProductImage(c.Request.Context(), productID, imageID, params)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product image not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update product image.", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, img)
+}
+
+// ReorderProductImages handles PUT /admin/products/{product_id}/images/order.
+// Body: {"image_ids": [3, 1, 2]} listing every image of the product.
+func (h ProductHandler) ReorderProductImages(c *gin.Context) {
+	productID, ok := h.parseAdminProductID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ImageIDs []int64 `json:"image_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	if err := h.Repo.ReorderProductImages(c.Request.Context(), productID, req.ImageIDs); err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "image_ids must list every image of the product exactly once.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to reorder product images.", nil)
+		return
+	}
+
+	images, err := h.Repo.GetProductImages(c.Request.Context(), productID, nil)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch product images.", nil)
+		return
+	}
+	c.JSON(http.StatusOK, images)
+}
+
+// DeleteProductImage handles DELETE /admin/products/{product_id}/images/{image_id}.
+func (h ProductHandler) DeleteProductImage(c *gin.Context) {
+	productID, ok := h.parseAdminProductID(c)
+	if !ok {
+		return
+	}
+	imageID, err := strconv.ParseInt(c.Param("image_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid image ID.", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	img, err := h.Repo.DeleteProductImage(ctx, productID, imageID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product image not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete product image.", nil)
+		return
+	}
+
+	h.deleteUnreferencedFile(ctx, img.URL)
+	h.deleteUnreferencedFile(ctx, img.ThumbnailURL)
+
+	c.Status(http.StatusNoContent)
+}
+
+// deleteUnreferencedFile removes a stored file once no image row points at it.
+// Files outside our storage (e.g. legacy seed paths) are left alone.
+func (h ProductHandler) deleteUnreferencedFile(ctx context.Context, url string) {
+	if url == "" {
+		return
+	}
+	key, ok := h.Storage.KeyFromURL(url)
+	if !ok {
+		return
+	}
+
+	refs, err := h.Repo.CountImagePathReferences(ctx, url)
+	if err != nil || refs > 0 {
+		return
+	}
+	if err := h.Storage.Delete(ctx, key); err != nil {
+		fmt.Printf("Failed to delete product image file %s: %v\n", key, err)
+	}
+}
+
+// putRendition resizes source to at most width pixels wide and stores it as JPEG.
+func (h ProductHandler) putRendition(ctx context.Context, key string, source image.Image, width int) error {
+	rendition := source
+	if source.Bounds().Dx() > width {
+		rendition = imaging.Resize(source, width, 0, imaging.Lanczos)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, rendition, &jpeg.Options{Quality: productImageQuality}); err != nil {
+		return err
+	}
+	return h.Storage.Put(ctx, key, &buf, "image/jpeg")
+}
+
+// parseAdminProductID reads product_id and confirms the product exists.
+func (h ProductHandler) parseAdminProductID(c *gin.Context) (int64, bool) {
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid product ID.", nil)
+		return 0, false
+	}
+
+	if _, err := h.Repo.GetProductByID(c.Request.Context(), productID); err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product not found.", nil)
+			return 0, false
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch product.", nil)
+		return 0, false
+	}
+	return productID, true
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// snippetRadius is how many characters of context surround the first match.
+const snippetRadius = 40
+
+// SuggestProducts handles GET /products/suggest?q=&limit= for search-box
+// autocomplete. limit applies per group (default 5, max 10).
+func (h ProductHandler) SuggestProducts(c *gin.Context) {
+	prefix := strings.TrimSpace(c.Query("q"))
+	if prefix == "" {
+		c.JSON(http.StatusOK, models.SearchSuggestions{
+			Products:   []models.ProductSuggestion{},
+			Hashtags:   []string{},
+			Categories: []models.CategorySuggestion{},
+		})
+		return
+	}
+
+	limit := 5
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 10 {
+			limit = l
+		}
+	}
+
+	suggestions, err := h.Repo.Suggest(c.Request.Context(), prefix, limit)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch suggestions.", nil)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=60")
+	c.JSON(http.StatusOK, suggestions)
+}
+
+// attachHighlights marks search matches in each product's name and a
+// description snippet. Matching is done on characters rather than words so
+// that Chinese phrases are highlighted too.
+func attachHighlights(products []models.ProductWithDetails, query string) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return
+	}
+	for i := range products {
+		p := &products[i]
+		highlight := &models.SearchHighlight{ProductName: markMatches([]rune(p.Name), terms)}
+		if p.Description != nil {
+			highlight.Snippet = snippet([]rune(*p.Description), terms)
+		}
+		p.Highlight = highlight
+	}
+}
+
+// searchTerms splits a websearch-style query into lower-cased terms, dropping
+// operators and quotes. The whole phrase is kept as a term as well.
+func searchTerms(query string) [][]rune {
+	var terms [][]rune
+	add := func(t string) {
+		t = strings.Trim(t, `"'`)
+		if t == "" || strings.EqualFold(t, "or") {
+			return
+		}
+		terms = append(terms, lowerRunes([]rune(t)))
+	}
+
+	add(strings.TrimSpace(query))
+	for _, field := range strings.Fields(query) {
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+		add(field)
+	}
+	return terms
+}
+
+// matchRanges flags every character of text covered by a term.
+func matchRanges(text []rune, terms [][]rune) []bool {
+	lower := lowerRunes(text)
+	marked := make([]bool, len(text))
+	for _, term := range terms {
+		for i := 0; i+len(term) <= len(lower); i++ {
+			if runesEqual(lower[i:i+len(term)], term) {
+				for j := i; j < i+len(term); j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+	return marked
+}
+
+// markMatches HTML-escapes text and wraps matched runs in <mark>.
+func markMatches(text []rune, terms [][]rune) string {
+	return renderMarked(text, matchRanges(text, terms))
+}
+
+// snippet returns the context around the first match, or "" when nothing matches.
+func snippet(text []rune, terms [][]rune) string {
+	marked := matchRanges(text, terms)
+	first := -1
+	for i, m := range marked {
+		if m {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	start := first - snippetRadius
+	if start < 0 {
+		start = 0
+	}
+	end := first + snippetRadius
+	if end > len(text) {
+		end = len(text)
+	}
+
+	out := renderMarked(text[start:end], marked[start:end])
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(text) {
+		out += "…"
+	}
+	return out
+}
+
+func renderMarked(text []rune, marked []bool) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		j := i
+		for j < len(text) && marked[j] == marked[i] {
+			j++
+		}
+		segment := html.EscapeString(string(text[i:j]))
+		if marked[i] {
+			b.WriteString("<mark>" + segment + "</mark>")
+		} else {
+			b.WriteString(segment)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func lowerRunes(r []rune) []rune {
+	out := make([]rune, len(r))
+	for i, c := range r {
+		out[i] = unicode.ToLower(c)
+	}
+	return out
+}
+
+func runesEqual(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
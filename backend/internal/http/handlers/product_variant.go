@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+)
+
+type productVariantRequest struct {
+	SKUSuffix      *string         `json:"sku_suffix" binding:"omitempty,max=50"`
+	Price          *float64        `json:"price" binding:"omitempty,gte=0"`
+	CompareAtPrice json.RawMessage `json:"compare_at_price"` // null clears it
+	Quantity       *int            `json:"quantity" binding:"omitempty,gte=0"`
+	IsActive       *bool           `json:"is_active"`
+}
+
+// UpsertProductVariant handles PUT /admin/products/{product_id}/variants/{size_type}.
+// Creates the size if missing; omitted fields keep their current values.
+func (h ProductHandler) UpsertProductVariant(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid product ID.", nil)
+		return
+	}
+	sizeType := models.SizeType(c.Param("size_type"))
+	if !sizeType.IsValid() {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid size_type.", nil)
+		return
+	}
+
+	var req productVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	product, err := h.Repo.GetProductByID(c.Request.Context(), productID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch product.", nil)
+		return
+	}
+	if product.Type == models.ProductTypeBundle {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Bundles are not sold per size; their stock comes from their components.", nil)
+		return
+	}
+
+	// Validate against the values the variant will end up with.
+	price := product.Price
+	var compareAt *float64
+	for _, v := range product.Variants {
+		if v.SizeType == sizeType {
+			price = v.Price
+			compareAt = v.ListedCompareAtPrice
+		}
+	}
+	if req.Price != nil {
+		price = *req.Price
+	}
+	params := repository.ProductVariantParams{
+		SKUSuffix: req.SKUSuffix,
+		Price:     req.Price,
+		Quantity:  req.Quantity,
+		IsActive:  req.IsActive,
+	}
+	if len(req.CompareAtPrice) > 0 {
+		if string(req.CompareAtPrice) == "null" {
+			params.ClearCompareAtPrice = true
+		} else {
+			var value float64
+			if err := json.Unmarshal(req.CompareAtPrice, &value); err != nil || value <= 0 {
+				writeValidationError(c, errors.New("compare_at_price must be a positive number or null"))
+				return
+			}
+			params.CompareAtPrice = &value
+		}
+		compareAt = params.CompareAtPrice
+	}
+	if err := validateCompareAtPrice(price, compareAt); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	variant, err := h.Repo.UpsertProductVariant(c.Request.Context(), productID, sizeType, params)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			writeError(c, http.StatusConflict, "SKU_EXISTS", "Another size of this product already uses this SKU suffix.", nil)
+			return
+		}
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save product variant.", nil)
+		return
+	}
+	h.notifyRestocked(productID)
+
+	c.JSON(http.StatusOK, models.NewAdminProductVariant(*variant))
+}
+
+// notifyRestocked sends back-in-stock alerts for the product in the
+// background; only subscriptions that can be bought now are alerted.
+func (h ProductHandler) notifyRestocked(productID int64) {
+	if h.StockAlerts == nil {
+		return
+	}
+	go h.StockAlerts.NotifyRestocked(context.Background(), productID)
+}
+
+// DeleteProductVariant handles DELETE /admin/products/{product_id}/variants/{size_type}.
+func (h ProductHandler) DeleteProductVariant(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid product ID.", nil)
+		return
+	}
+	sizeType := models.SizeType(c.Param("size_type"))
+	if !sizeType.IsValid() {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid size_type.", nil)
+		return
+	}
+
+	if err := h.Repo.DeleteProductVariant(c.Request.Context(), productID, sizeType); err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product variant not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete product variant.", nil)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	_ "image/png" // Register PNG decoder
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/disintegration/imaging"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	httpmw "github.com/ryangel/ryangel-backend/internal/http/middleware"
+	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+	"github.com/ryangel/ryangel-backend/internal/storage"
+	authsvc "github.com/ryangel/ryangel-backend/internal/services/auth"
+)
+
+const (
+	maxReviewBodyLength = 2000
+	maxReviewPhotoBytes = 5 * 1024 * 1024
+	reviewPhotoWidth    = 1024
+)
+
+// ReviewHandler serves product reviews. Reviews are posted by clients who
+// received the product and shown once an admin approves them.
+type ReviewHandler struct {
+	Reviews *repository.ReviewRepository
+	Storage storage.Storage
+}
+
+// Register wires the public and client review routes onto the router.
+func (h ReviewHandler) Register(rg *gin.RouterGroup, authSvc *authsvc.Service) {
+	rg.GET("/products/:product_id/reviews", h.listProductReviews)
+
+	client := rg.Group("/products")
+	if authSvc != nil {
+		client.Use(httpmw.ClientAuth(authSvc))
+	}
+	client.POST("/:product_id/reviews", h.createReview)
+}
+
+// RegisterAdmin wires the moderation routes onto the router.
+func (h ReviewHandler) RegisterAdmin(rg *gin.RouterGroup, authSvc *authsvc.Service) {
+	admin := rg.Group("/admin/reviews")
+	if authSvc != nil {
+		admin.Use(httpmw.AdminAuth(authSvc))
+	}
+	admin.GET("", h.adminListReviews)
+	admin.PATCH("/:review_id", h.adminUpdateReview)
+	admin.DELETE("/:review_id", h.adminDeleteReview)
+}
+
+// listProductReviews handles GET /products/{product_id}/reviews.
+func (h ReviewHandler) listProductReviews(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid product ID.", nil)
+		return
+	}
+	page, pageSize := reviewPage(c)
+
+	reviews, rating, err := h.Reviews.ListApproved(c.Request.Context(), productID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Product not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch reviews.", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ProductReviewListResponse{
+		Data:   reviews,
+		Meta:   paginationMeta(page, pageSize, rating.Count),
+		Rating: rating,
+	})
+}
+
+// createReview handles POST /products/{product_id}/reviews.
+// Accepts multipart form: rating (1-5), body, photo (JPEG/PNG, max 5MB).
+func (h ReviewHandler) createReview(c *gin.Context) {
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return
+	}
+
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid product ID.", nil)
+		return
+	}
+
+	rating, err := strconv.Atoi(c.PostForm("rating"))
+	if err != nil || rating < 1 || rating > 5 {
+		writeValidationError(c, fmt.Errorf("rating must be a whole number from 1 to 5"))
+		return
+	}
+	review := models.ProductReview{ProductID: productID, ClientID: client.ID, Rating: rating}
+	if body := strings.TrimSpace(c.PostForm("body")); body != "" {
+		if utf8.RuneCountInString(body) > maxReviewBodyLength {
+			writeValidationError(c, fmt.Errorf("body is longer than %d characters", maxReviewBodyLength))
+			return
+		}
+		review.Body = &body
+	}
+
+	ctx := c.Request.Context()
+	orderID, err := h.Reviews.FindDeliveredOrder(ctx, client.ID, productID)
+	if err != nil {
+		if err == repository.ErrNotVerifiedBuyer {
+			writeError(c, http.StatusForbidden, "NOT_VERIFIED_BUYER", "Only customers who received this product can review it.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to check order history.", nil)
+		return
+	}
+	review.OrderID = &orderID
+
+	fileHeader, err := c.FormFile("photo")
+	if err == nil {
+		photoURL, ok := h.storePhoto(c, productID, fileHeader)
+		if !ok {
+			return
+		}
+		review.PhotoURL = &photoURL
+	} else if err != http.ErrMissingFile {
+		writeError(c, http.StatusBadRequest, "UPLOAD_ERROR", "Error uploading file.", nil)
+		return
+	}
+
+	if err := h.Reviews.Create(ctx, &review); err != nil {
+		h.deletePhoto(ctx, review.PhotoURL)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			writeError(c, http.StatusConflict, "REVIEW_EXISTS", "You have already reviewed this product.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save review.", nil)
+		return
+	}
+
+	review.ReviewerName = client.Username
+	c.JSON(http.StatusCreated, review)
+}
+
+// storePhoto resizes an uploaded review photo and stores it as JPEG in media
+// storage, returning its public URL.
+func (h ReviewHandler) storePhoto(c *gin.Context, productID int64, fileHeader *multipart.FileHeader) (string, bool) {
+	if fileHeader.Size > maxReviewPhotoBytes {
+		writeError(c, http.StatusBadRequest, "UPLOAD_ERROR", "File too large (max 5MB).", nil)
+		return "", false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "UPLOAD_ERROR", "Failed to open file.", nil)
+		return "", false
+	}
+	defer file.Close()
+
+	img, err := decodeUpload(file)
+	if err != nil {
+		writeDecodeError(c, err)
+		return "", false
+	}
+	if img.Bounds().Dx() > reviewPhotoWidth {
+		img = imaging.Resize(img, reviewPhotoWidth, 0, imaging.Lanczos)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: productImageQuality}); err != nil {
+		writeError(c, http.StatusInternalServerError, "UPLOAD_ERROR", "Failed to encode image.", nil)
+		return "", false
+	}
+
+	key, err := storage.RandomKey(fmt.Sprintf("reviews/%d", productID), ".jpg")
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "UPLOAD_ERROR", "Internal server error.", nil)
+		return "", false
+	}
+	if err := h.Storage.Put(c.Request.Context(), key, &buf, "image/jpeg"); err != nil {
+		fmt.Printf("Failed to store review photo %s: %v\n", key, err)
+		writeError(c, http.StatusInternalServerError, "UPLOAD_ERROR", "Failed to save image.", nil)
+		return "", false
+	}
+	return h.Storage.URL(key), true
+}
+
+// deletePhoto removes a review photo from media storage, if there is one.
+func (h ReviewHandler) deletePhoto(ctx context.Context, photoURL *string) {
+	if photoURL == nil {
+		return
+	}
+	key, ok := h.Storage.KeyFromURL(*photoURL)
+	if !ok {
+		return
+	}
+	if err := h.Storage.Delete(ctx, key); err != nil {
+		fmt.Printf("Failed to delete review photo %s: %v\n", key, err)
+	}
+}
+
+// adminListReviews handles GET /admin/reviews. Optional ?status= and
+// ?product_id= narrow the list; it defaults to the pending queue.
+func (h ReviewHandler) adminListReviews(c *gin.Context) {
+	page, pageSize := reviewPage(c)
+
+	var filters repository.ReviewFilters
+	status := models.ReviewStatusPending
+	if statusStr := c.Query("status"); statusStr != "" {
+		status = models.ReviewStatus(statusStr)
+	}
+	if status != "all" {
+		if !status.IsValid() {
+			writeValidationError(c, fmt.Errorf("invalid status %q", status))
+			return
+		}
+		filters.Status = &status
+	}
+	if productIDStr := c.Query("product_id"); productIDStr != "" {
+		productID, err := strconv.ParseInt(productIDStr, 10, 64)
+		if err != nil {
+			writeValidationError(c, fmt.Errorf("invalid product_id %q", productIDStr))
+			return
+		}
+		filters.ProductID = &productID
+	}
+
+	reviews, total, err := h.Reviews.ListForModeration(c.Request.Context(), filters, pageSize, (page-1)*pageSize)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch reviews.", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AdminReviewListResponse{
+		Data: reviews,
+		Meta: paginationMeta(page, pageSize, total),
+	})
+}
+
+type updateReviewRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// adminUpdateReview handles PATCH /admin/reviews/{review_id}.
+func (h ReviewHandler) adminUpdateReview(c *gin.Context) {
+	reviewID, ok := parseReviewID(c)
+	if !ok {
+		return
+	}
+
+	var req updateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	status := models.ReviewStatus(req.Status)
+	if !status.IsValid() {
+		writeValidationError(c, fmt.Errorf("invalid status %q", req.Status))
+		return
+	}
+
+	admin, ok := httpmw.AdminFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "AUTH_INVALID_CREDENTIALS", "Missing authentication context.", nil)
+		return
+	}
+
+	review, err := h.Reviews.SetStatus(c.Request.Context(), reviewID, status, admin.ID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Review not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update review.", nil)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+// adminDeleteReview handles DELETE /admin/reviews/{review_id}, removing the
+// review and its photo.
+func (h ReviewHandler) adminDeleteReview(c *gin.Context) {
+	reviewID, ok := parseReviewID(c)
+	if !ok {
+		return
+	}
+
+	photoURL, err := h.Reviews.Delete(c.Request.Context(), reviewID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Review not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete review.", nil)
+		return
+	}
+	h.deletePhoto(c.Request.Context(), photoURL)
+	c.Status(http.StatusNoContent)
+}
+
+func parseReviewID(c *gin.Context) (int64, bool) {
+	reviewID, err := strconv.ParseInt(c.Param("review_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid review ID.", nil)
+		return 0, false
+	}
+	return reviewID, true
+}
+
+// reviewPage reads ?page= and ?page_size= (default 10, max 50).
+func reviewPage(c *gin.Context) (int, int) {
+	page := 1
+	if pageStr := c.Query("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+	pageSize := 10
+	if sizeStr := c.Query("page_size"); sizeStr != "" {
+		if s, err := strconv.Atoi(sizeStr); err == nil && s > 0 && s <= 50 {
+			pageSize = s
+		}
+	}
+	return page, pageSize
+}
+
+func paginationMeta(page, pageSize, total int) models.PaginationMeta {
+	return models.PaginationMeta{
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	httpmw "github.com/ryangel/ryangel-backend/internal/http/middleware"
+	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+	authsvc "github.com/ryangel/ryangel-backend/internal/services/auth"
+)
+
+// quoteShipping prices shipping a cart by method to regions (nil for
+// anywhere). It also returns the item discount, which is known even when no
+// rate covers the cart and the error is repository.ErrNoShippingRate.
+func (h CartHandler) quoteShipping(ctx context.Context, cartID string, items []models.CartItemResponse, method models.ShippingMethod, regions []string) (*models.ShippingQuote, float64, error) {
+	itemDiscountAmount, freeShipping := h.autoDiscounts(ctx, items)
+	subtotal, discountedSubtotal := cartSubtotals(items, itemDiscountAmount)
+
+	weight, err := h.ShippingRates.CartWeight(ctx, cartID)
+	if err != nil {
+		return nil, itemDiscountAmount, err
+	}
+	rate, err := h.ShippingRates.Find(ctx, method, regions, weight)
+	if err != nil {
+		return nil, itemDiscountAmount, err
+	}
+
+	quote := &models.ShippingQuote{
+		Method:             method,
+		RateID:             rate.ID,
+		RateName:           rate.Name,
+		Weight:             weight,
+		Subtotal:           subtotal,
+		DiscountedSubtotal: discountedSubtotal,
+		ShippingFee:        rate.Charge(weight),
+		FreeOver:           rate.FreeOver,
+	}
+	quote.DiscountedShippingFee = quote.ShippingFee
+	if freeShipping || rate.IsFreeFor(discountedSubtotal) {
+		quote.DiscountedShippingFee = 0
+	} else if rate.FreeOver != nil {
+		remaining := math.Round((*rate.FreeOver-discountedSubtotal)*100) / 100
+		quote.AmountToFreeShipping = &remaining
+	}
+	return quote, itemDiscountAmount, nil
+}
+
+// shippingDestination reads where the cart would ship from the query:
+// shipping_method (ebuy_pickup unless a shipping_address_id is given), and
+// for home delivery either shipping_address_id (signed-in clients) or region.
+// It writes the error response when the query is invalid.
+func (h CartHandler) shippingDestination(c *gin.Context) (models.ShippingMethod, []string, bool) {
+	idStr := c.Query("shipping_address_id")
+	method := models.ShippingMethodPickup
+	if idStr != "" {
+		method = models.ShippingMethodDelivery
+	}
+	if m := c.Query("shipping_method"); m != "" {
+		method = models.ShippingMethod(m)
+		if !method.IsValid() {
+			writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid shipping_method", nil)
+			return "", nil, false
+		}
+	}
+	if method != models.ShippingMethodDelivery {
+		if idStr != "" {
+			writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "shipping_address_id is only used for home_delivery", nil)
+			return "", nil, false
+		}
+		return method, nil, true
+	}
+
+	if idStr == "" {
+		if region := c.Query("region"); region != "" {
+			return method, models.ShippingRegions(region, "", ""), true
+		}
+		return method, nil, true
+	}
+	addressID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid shipping_address_id", nil)
+		return "", nil, false
+	}
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return "", nil, false
+	}
+	address, err := h.Addresses.Get(c.Request.Context(), client.ID, addressID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Address not found.", nil)
+			return "", nil, false
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch address.", nil)
+		return "", nil, false
+	}
+	return method, models.ShippingRegions(address.City, address.State, address.Country), true
+}
+
+// QuoteShipping handles GET /shipping/quote, pricing shipping for the current
+// cart to the destination read by shippingDestination.
+func (h CartHandler) QuoteShipping(c *gin.Context) {
+	method, regions, ok := h.shippingDestination(c)
+	if !ok {
+		return
+	}
+
+	cartID, err := h.getOrCreateCartID(c)
+	if err != nil {
+		if err.Error() == "cart not found" || err.Error() == "record not found" {
+			writeError(c, http.StatusNotFound, "CART_NOT_FOUND", "Cart not found", nil)
+		} else {
+			writeError(c, http.StatusBadRequest, "INVALID_CART", err.Error(), nil)
+		}
+		return
+	}
+	items, err := h.Repo.GetCartItems(c.Request.Context(), cartID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get cart items", nil)
+		return
+	}
+
+	quote, _, err := h.quoteShipping(c.Request.Context(), cartID, items, method, regions)
+	if err != nil {
+		if err == repository.ErrNoShippingRate {
+			writeShippingUnavailable(c)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to quote shipping.", nil)
+		return
+	}
+	c.JSON(http.StatusOK, quote)
+}
+
+func writeShippingUnavailable(c *gin.Context) {
+	writeError(c, http.StatusUnprocessableEntity, "SHIPPING_UNAVAILABLE", "No shipping rate covers this order.", nil)
+}
+
+// ShippingRateHandler serves shipping rate management.
+type ShippingRateHandler struct {
+	Repo *repository.ShippingRateRepository
+}
+
+// RegisterAdmin wires the shipping rate management routes onto the router.
+func (h ShippingRateHandler) RegisterAdmin(rg *gin.RouterGroup, authSvc *authsvc.Service) {
+	admin := rg.Group("/admin/shipping-rates")
+	if authSvc != nil {
+		admin.Use(httpmw.AdminAuth(authSvc))
+	}
+	admin.GET("", h.ListRates)
+	admin.POST("", h.CreateRate)
+	admin.PUT("/:rate_id", h.UpdateRate)
+	admin.DELETE("/:rate_id", h.DeleteRate)
+}
+
+// shippingRateRequest is the payload for creating or replacing a rate.
+type shippingRateRequest struct {
+	Name      string   `json:"rate_name" binding:"required,max=100"`
+	Method    string   `json:"shipping_method" binding:"required"`
+	Region    *string  `json:"region" binding:"omitempty,max=100"`
+	MinWeight float64  `json:"min_weight" binding:"gte=0"`
+	MaxWeight *float64 `json:"max_weight"`
+	BaseFee   float64  `json:"base_fee" binding:"gte=0"`
+	FeePerKg  float64  `json:"fee_per_kg" binding:"gte=0"`
+	FreeOver  *float64 `json:"free_over" binding:"omitempty,gte=0"`
+	Priority  int      `json:"priority"`
+	IsActive  *bool    `json:"is_active"`
+}
+
+func (r shippingRateRequest) toRate() (models.ShippingRate, error) {
+	rate := models.ShippingRate{
+		Name:      strings.TrimSpace(r.Name),
+		Method:    models.ShippingMethod(r.Method),
+		MinWeight: r.MinWeight,
+		MaxWeight: r.MaxWeight,
+		BaseFee:   r.BaseFee,
+		FeePerKg:  r.FeePerKg,
+		FreeOver:  r.FreeOver,
+		Priority:  r.Priority,
+		IsActive:  r.IsActive == nil || *r.IsActive,
+	}
+	if rate.Name == "" {
+		return rate, errors.New("rate_name cannot be empty")
+	}
+	if !rate.Method.IsValid() {
+		return rate, errors.New("shipping_method must be ebuy_pickup or home_delivery")
+	}
+	if r.Region != nil {
+		if region := strings.TrimSpace(*r.Region); region != "" {
+			if rate.Method != models.ShippingMethodDelivery {
+				return rate, errors.New("only home_delivery rates can have a region")
+			}
+			rate.Region = &region
+		}
+	}
+	if rate.MaxWeight != nil && *rate.MaxWeight <= rate.MinWeight {
+		return rate, errors.New("max_weight must be greater than min_weight")
+	}
+	return rate, nil
+}
+
+// ListRates handles GET /admin/shipping-rates.
+func (h ShippingRateHandler) ListRates(c *gin.Context) {
+	rates, err := h.Repo.List(c.Request.Context())
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch shipping rates.", nil)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rates})
+}
+
+// CreateRate handles POST /admin/shipping-rates.
+func (h ShippingRateHandler) CreateRate(c *gin.Context) {
+	var req shippingRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	rate, err := req.toRate()
+	if err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	created, err := h.Repo.Create(c.Request.Context(), rate)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save shipping rate.", nil)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateRate handles PUT /admin/shipping-rates/{rate_id}, replacing the rate.
+func (h ShippingRateHandler) UpdateRate(c *gin.Context) {
+	rateID, ok := parseRateID(c)
+	if !ok {
+		return
+	}
+	var req shippingRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	rate, err := req.toRate()
+	if err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	updated, err := h.Repo.Update(c.Request.Context(), rateID, rate)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Shipping rate not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save shipping rate.", nil)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteRate handles DELETE /admin/shipping-rates/{rate_id}.
+func (h ShippingRateHandler) DeleteRate(c *gin.Context) {
+	rateID, ok := parseRateID(c)
+	if !ok {
+		return
+	}
+	if err := h.Repo.Delete(c.Request.Context(), rateID); err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Shipping rate not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete shipping rate.", nil)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func parseRateID(c *gin.Context) (int64, bool) {
+	rateID, err := strconv.ParseInt(c.Param("rate_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid shipping rate ID.", nil)
+		return 0, false
+	}
+	return rateID, true
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	httpmw "github.com/ryangel/ryangel-backend/internal/http/middleware"
+	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+	authsvc "github.com/ryangel/ryangel-backend/internal/services/auth"
+)
+
+// StockAlertHandler manages the signed-in client's back-in-stock subscriptions.
+type StockAlertHandler struct {
+	Repo *repository.StockAlertRepository
+}
+
+// Register wires the stock alert routes onto the router.
+func (h StockAlertHandler) Register(rg *gin.RouterGroup, authSvc *authsvc.Service) {
+	alerts := rg.Group("/clients/me/stock-alerts")
+	if authSvc != nil {
+		alerts.Use(httpmw.ClientAuth(authSvc))
+	}
+	alerts.GET("", h.listStockAlerts)
+	alerts.POST("", h.subscribe)
+	alerts.DELETE("/:subscription_id", h.unsubscribe)
+}
+
+// listStockAlerts handles GET /clients/me/stock-alerts.
+func (h StockAlertHandler) listStockAlerts(c *gin.Context) {
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return
+	}
+
+	subs, err := h.Repo.ListByClient(c.Request.Context(), client.ID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch stock alerts.", nil)
+		return
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+type stockAlertRequest struct {
+	ProductID int64   `json:"product_id" binding:"required"`
+	SizeType  *string `json:"size_type"`
+	Channel   string  `json:"channel"`
+}
+
+// subscribe handles POST /clients/me/stock-alerts.
+func (h StockAlertHandler) subscribe(c *gin.Context) {
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return
+	}
+
+	var req stockAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	sizeType, ok := parseOptionalSizeType(c, req.SizeType)
+	if !ok {
+		return
+	}
+
+	channel := models.StockAlertChannelSMS
+	if req.Channel != "" {
+		channel = models.StockAlertChannel(req.Channel)
+	}
+	if !channel.IsValid() {
+		writeValidationError(c, fmt.Errorf("invalid channel %q", req.Channel))
+		return
+	}
+	if channel == models.StockAlertChannelEmail && (client.Email == nil || *client.Email == "") {
+		writeValidationError(c, fmt.Errorf("add an email address to your account to get email alerts"))
+		return
+	}
+	if channel == models.StockAlertChannelSMS && (client.Phone == nil || *client.Phone == "") {
+		writeValidationError(c, fmt.Errorf("add a phone number to your account to get SMS alerts"))
+		return
+	}
+
+	sub, err := h.Repo.Subscribe(c.Request.Context(), client.ID, req.ProductID, sizeType, channel)
+	if err != nil {
+		if err == repository.ErrInStock {
+			writeError(c, http.StatusConflict, "IN_STOCK", "This product is in stock.", nil)
+			return
+		}
+		if writeStockError(c, err) {
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save stock alert.", nil)
+		return
+	}
+	c.JSON(http.StatusCreated, sub)
+}
+
+// unsubscribe handles DELETE /clients/me/stock-alerts/{subscription_id}.
+func (h StockAlertHandler) unsubscribe(c *gin.Context) {
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return
+	}
+	subscriptionID, err := strconv.ParseInt(c.Param("subscription_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid subscription ID.", nil)
+		return
+	}
+
+	if err := h.Repo.Unsubscribe(c.Request.Context(), client.ID, subscriptionID); err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Stock alert not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete stock alert.", nil)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
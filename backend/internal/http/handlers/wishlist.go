@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	httpmw "github.com/ryangel/ryangel-backend/internal/http/middleware"
+	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+	authsvc "github.com/ryangel/ryangel-backend/internal/services/auth"
+)
+
+// WishlistHandler serves the signed-in client's wishlist.
+type WishlistHandler struct {
+	Wishlist *repository.WishlistRepository
+	Carts    *repository.CartRepository
+}
+
+// Register wires the wishlist routes onto the router.
+func (h WishlistHandler) Register(rg *gin.RouterGroup, authSvc *authsvc.Service) {
+	wishlist := rg.Group("/clients/me/wishlist")
+	if authSvc != nil {
+		wishlist.Use(httpmw.ClientAuth(authSvc))
+	}
+	wishlist.GET("", h.listWishlist)
+	wishlist.POST("", h.addToWishlist)
+	wishlist.DELETE("/:wishlist_item_id", h.removeFromWishlist)
+	wishlist.POST("/:wishlist_item_id/move-to-cart", h.moveToCart)
+}
+
+// listWishlist handles GET /clients/me/wishlist.
+func (h WishlistHandler) listWishlist(c *gin.Context) {
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return
+	}
+
+	items, err := h.Wishlist.List(c.Request.Context(), client.ID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch wishlist.", nil)
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+type wishlistRequest struct {
+	ProductID int64   `json:"product_id" binding:"required"`
+	SizeType  *string `json:"size_type"`
+}
+
+// addToWishlist handles POST /clients/me/wishlist. Saving an entry that is
+// already there returns it with 200 instead of 201.
+func (h WishlistHandler) addToWishlist(c *gin.Context) {
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return
+	}
+
+	var req wishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	sizeType, ok := parseOptionalSizeType(c, req.SizeType)
+	if !ok {
+		return
+	}
+
+	item, created, err := h.Wishlist.Add(c.Request.Context(), client.ID, req.ProductID, sizeType)
+	if err != nil {
+		if writeStockError(c, err) {
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save to wishlist.", nil)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, item)
+}
+
+// removeFromWishlist handles DELETE /clients/me/wishlist/{wishlist_item_id}.
+func (h WishlistHandler) removeFromWishlist(c *gin.Context) {
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return
+	}
+	itemID, ok := parseWishlistItemID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Wishlist.Remove(c.Request.Context(), client.ID, itemID); err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Wishlist item not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to remove wishlist item.", nil)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+type moveToCartRequest struct {
+	Quantity int `json:"quantity" binding:"omitempty,min=1"`
+	// SizeType picks a size for entries saved without one.
+	SizeType *string `json:"size_type"`
+}
+
+// moveToCart handles POST /clients/me/wishlist/{wishlist_item_id}/move-to-cart.
+// The item is added to the client's cart with the usual stock checks and
+// removed from the wishlist once it is in the cart.
+func (h WishlistHandler) moveToCart(c *gin.Context) {
+	client, ok := httpmw.ClientFromContext(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not logged in", nil)
+		return
+	}
+	itemID, ok := parseWishlistItemID(c)
+	if !ok {
+		return
+	}
+
+	var req moveToCartRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			writeValidationError(c, err)
+			return
+		}
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	ctx := c.Request.Context()
+	item, err := h.Wishlist.Get(ctx, client.ID, itemID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			writeError(c, http.StatusNotFound, "NOT_FOUND", "Wishlist item not found.", nil)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch wishlist item.", nil)
+		return
+	}
+
+	sizeType := item.SizeType
+	if sizeType == nil {
+		if sizeType, ok = parseOptionalSizeType(c, req.SizeType); !ok {
+			return
+		}
+	}
+
+	cart, err := h.Carts.GetCartByClientID(ctx, client.ID)
+	if err == repository.ErrNotFound {
+		cart, err = h.Carts.CreateCart(ctx, &client.ID)
+	}
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load cart.", nil)
+		return
+	}
+
+	if err := h.Carts.AddItemToCart(ctx, cart.CartID, item.ProductID, sizeType, nil, req.Quantity); err != nil {
+		if writeStockError(c, err) {
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to add item to cart.", nil)
+		return
+	}
+	if err := h.Wishlist.Remove(ctx, client.ID, itemID); err != nil && err != repository.ErrNotFound {
+		fmt.Printf("Failed to remove wishlist item %d after moving it to cart: %v\n", itemID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item moved to cart", "cart_id": cart.CartID})
+}
+
+func parseWishlistItemID(c *gin.Context) (int64, bool) {
+	itemID, err := strconv.ParseInt(c.Param("wishlist_item_id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid wishlist item ID.", nil)
+		return 0, false
+	}
+	return itemID, true
+}
+
+// parseOptionalSizeType validates an optional size_type from a request body.
+func parseOptionalSizeType(c *gin.Context, value *string) (*models.SizeType, bool) {
+	if value == nil || *value == "" {
+		return nil, true
+	}
+	sizeType := models.SizeType(*value)
+	if !sizeType.IsValid() {
+		writeError(c, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid size_type", nil)
+		return nil, false
+	}
+	return &sizeType, true
+}
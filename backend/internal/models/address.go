@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// ClientAddress is an entry in a client's address book. At most one address
+// per client is the default.
+type ClientAddress struct {
+	ID           int64     `json:"address_id"`
+	ClientID     int64     `json:"client_id"`
+	AddressType  string    `json:"address_type"`
+	AddressLine1 string    `json:"address_line1"`
+	AddressLine2 *string   `json:"address_line2"`
+	City         string    `json:"city"`
+	State        string    `json:"state"`
+	PostalCode   string    `json:"postal_code"`
+	Country      string    `json:"country"`
+	Phone        *string   `json:"phone"`
+	IsDefault    bool      `json:"is_default"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
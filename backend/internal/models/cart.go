@@ -31,6 +31,7 @@ type CartItem struct {
 type CartItemResponse struct {
 	CartItemID    int64     `json:"cart_item_id"`
 	ProductID     int64     `json:"product_id"`
+	VariantID     *int64    `json:"variant_id"`
 	SizeType      *SizeType `json:"size_type"`
 	Quantity      int       `json:"quantity"`
 	AddedAt       time.Time `json:"added_at"`
@@ -39,6 +40,9 @@ type CartItemResponse struct {
 	UnitPrice     float64   `json:"unit_price"`
 	StockQuantity int       `json:"stock_quantity"`
 	ThumbnailURL  string    `json:"thumbnail_url"`
+	// Customisations are the chosen options; their surcharges are included
+	// in UnitPrice.
+	Customisations []Customisation `json:"customisations"`
 }
 
 // CartWithItems represents a cart with its items.
@@ -0,0 +1,40 @@
+package models
+
+// CategoryNode is a category in the nested category tree.
+type CategoryNode struct {
+	Category
+	// ProductCount counts active products in this category or any descendant.
+	ProductCount int            `json:"product_count"`
+	Children     []CategoryNode `json:"children"`
+}
+
+// BuildCategoryTree nests flat nodes under their parents. Nodes whose parent is
+// not in the list become roots, so filtering out a category hides its subtree
+// only if the caller also drops the descendants. Input order is preserved.
+func BuildCategoryTree(nodes []CategoryNode) []CategoryNode {
+	present := make(map[int64]bool, len(nodes))
+	children := make(map[int64][]CategoryNode)
+	for _, n := range nodes {
+		present[n.ID] = true
+	}
+
+	var roots []CategoryNode
+	for _, n := range nodes {
+		if n.ParentID != nil && present[*n.ParentID] {
+			children[*n.ParentID] = append(children[*n.ParentID], n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+
+	var attach func(list []CategoryNode) []CategoryNode
+	attach = func(list []CategoryNode) []CategoryNode {
+		out := make([]CategoryNode, len(list))
+		for i, n := range list {
+			n.Children = attach(children[n.ID])
+			out[i] = n
+		}
+		return out
+	}
+	return attach(roots)
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Collection is a hand-curated set of products with its own landing page,
+// e.g. a Lunar New Year range.
+type Collection struct {
+	ID          int64      `json:"collection_id"`
+	Name        string     `json:"collection_name"`
+	Slug        string     `json:"slug"`
+	Description *string    `json:"collection_description"`
+	SortOrder   int        `json:"sort_order"`
+	IsActive    bool       `json:"is_active"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+	// ProductCount counts the listed products in the collection.
+	ProductCount int       `json:"product_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CollectionLandingResponse is a collection with a page of its products.
+type CollectionLandingResponse struct {
+	Collection Collection           `json:"collection"`
+	Data       []ProductWithDetails `json:"data"`
+	Meta       PaginationMeta       `json:"meta"`
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// CustomisationType is how a customisation option is filled in.
+type CustomisationType string
+
+const (
+	// CustomisationTypeText takes free text up to MaxLength characters,
+	// e.g. custom wording on a faiachun.
+	CustomisationTypeText CustomisationType = "text"
+	// CustomisationTypeChoice takes one of Choices, e.g. a font.
+	CustomisationTypeChoice CustomisationType = "choice"
+)
+
+// IsValid reports whether t matches customisation_type_enum.
+func (t CustomisationType) IsValid() bool {
+	switch t {
+	case CustomisationTypeText, CustomisationTypeChoice:
+		return true
+	}
+	return false
+}
+
+// CustomisationOption is a personalisation a product offers. Surcharge is
+// added to the unit price when the option is filled in.
+type CustomisationOption struct {
+	ID         int64             `json:"option_id"`
+	ProductID  int64             `json:"product_id"`
+	Key        string            `json:"option_key"`
+	Label      string            `json:"label"`
+	Type       CustomisationType `json:"option_type"`
+	MaxLength  *int              `json:"max_length"`
+	Choices    []string          `json:"choices"`
+	IsRequired bool              `json:"is_required"`
+	Surcharge  float64           `json:"surcharge"`
+}
+
+// Customisation is a filled-in option with the label and surcharge it had
+// when chosen; order items keep it as a snapshot.
+type Customisation struct {
+	Key       string  `json:"option_key"`
+	Label     string  `json:"label"`
+	Value     string  `json:"value"`
+	Surcharge float64 `json:"surcharge"`
+}
+
+// CustomisationError explains why customisation values were rejected.
+type CustomisationError struct {
+	Key    string
+	Label  string
+	Reason string
+}
+
+func (e *CustomisationError) Error() string {
+	name := e.Label
+	if name == "" {
+		name = e.Key
+	}
+	return name + " " + e.Reason
+}
+
+// ApplyCustomisations checks values, keyed by option key, against a
+// product's options. It returns the filled-in options in option order and
+// their total surcharge. Values are trimmed and empty ones count as unset.
+func ApplyCustomisations(options []CustomisationOption, values map[string]string) ([]Customisation, float64, error) {
+	known := make(map[string]bool, len(options))
+	for _, o := range options {
+		known[o.Key] = true
+	}
+	for key := range values {
+		if !known[key] {
+			return nil, 0, &CustomisationError{Key: key, Reason: "is not offered for this product"}
+		}
+	}
+
+	chosen := []Customisation{}
+	var surcharge float64
+	for _, o := range options {
+		value := strings.TrimSpace(values[o.Key])
+		if value == "" {
+			if o.IsRequired {
+				return nil, 0, &CustomisationError{Key: o.Key, Label: o.Label, Reason: "is required"}
+			}
+			continue
+		}
+		switch o.Type {
+		case CustomisationTypeText:
+			if o.MaxLength != nil && utf8.RuneCountInString(value) > *o.MaxLength {
+				return nil, 0, &CustomisationError{Key: o.Key, Label: o.Label, Reason: fmt.Sprintf("must be at most %d characters", *o.MaxLength)}
+			}
+		case CustomisationTypeChoice:
+			valid := false
+			for _, choice := range o.Choices {
+				if choice == value {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return nil, 0, &CustomisationError{Key: o.Key, Label: o.Label, Reason: fmt.Sprintf("must be one of: %s", strings.Join(o.Choices, ", "))}
+			}
+		}
+		chosen = append(chosen, Customisation{Key: o.Key, Label: o.Label, Value: value, Surcharge: o.Surcharge})
+		surcharge += o.Surcharge
+	}
+	return chosen, surcharge, nil
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// SitemapProduct is an active product listed in sitemap.xml.
+type SitemapProduct struct {
+	ID        int64
+	Slug      string
+	UpdatedAt time.Time
+}
+
+// SitemapCategory is a visible category listed in sitemap.xml. LastMod is the
+// latest change to the category or any active product in its subtree.
+type SitemapCategory struct {
+	ID      int64
+	LastMod time.Time
+}
+
+// FeedItem is one sellable item in the merchant product feed. Products sold
+// per size yield one item per active variant, grouped by the product SKU.
+type FeedItem struct {
+	ID             string // full SKU of the variant or product
+	GroupID        string // product SKU when the item is a variant
+	ProductID      int64
+	Slug           string
+	Title          string
+	Description    string
+	Type           ProductType
+	SizeType       *SizeType
+	Price          float64
+	CompareAtPrice *float64
+	Quantity       int
+	ImageURLs      []string // best image for the size first
+}
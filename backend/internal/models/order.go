@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
@@ -36,6 +37,48 @@ const (
 	PaymentStatusRefunded PaymentStatus = "refunded"
 )
 
+// ShippingMethod is how an order reaches the client: collected from an ebuy
+// store or delivered to one of the client's addresses.
+type ShippingMethod string
+
+const (
+	ShippingMethodPickup   ShippingMethod = "ebuy_pickup"
+	ShippingMethodDelivery ShippingMethod = "home_delivery"
+)
+
+// IsValid reports whether m is a known shipping method.
+func (m ShippingMethod) IsValid() bool {
+	switch m {
+	case ShippingMethodPickup, ShippingMethodDelivery:
+		return true
+	}
+	return false
+}
+
+// AddressSnapshot is a delivery address as it was when the order was placed,
+// so later address book edits do not change the order.
+type AddressSnapshot struct {
+	AddressType  string  `json:"address_type"`
+	AddressLine1 string  `json:"address_line1"`
+	AddressLine2 *string `json:"address_line2"`
+	City         string  `json:"city"`
+	State        string  `json:"state"`
+	PostalCode   string  `json:"postal_code"`
+	Country      string  `json:"country"`
+	Phone        *string `json:"phone"`
+}
+
+// SingleLine joins the non-empty address parts with commas.
+func (a AddressSnapshot) SingleLine() string {
+	var parts []string
+	for _, p := range []*string{&a.AddressLine1, a.AddressLine2, &a.City, &a.State, &a.PostalCode, &a.Country} {
+		if p != nil && *p != "" {
+			parts = append(parts, *p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
 // Order represents an order in the system.
 type Order struct {
 	OrderID          int64          `json:"order_id"`
@@ -51,6 +94,9 @@ type Order struct {
 	DiscountCode     *string        `json:"discount_code"`
 	ShippingAddressID *int64        `json:"shipping_address_id"`
 	EbuyStoreID      *string        `json:"ebuy_store_id"`
+	ShippingMethod   ShippingMethod `json:"shipping_method"`
+	// ShippingAddress is set on home delivery orders.
+	ShippingAddress  *AddressSnapshot `json:"shipping_address"`
 	ContactPhone     string         `json:"contact_phone"`
 	PaymentMethod    PaymentMethod  `json:"payment_method"`
 	PaymentStatus    PaymentStatus  `json:"payment_status"`
@@ -64,7 +110,8 @@ type Order struct {
 	CancelledAt      *time.Time     `json:"cancelled_at"`
 	CustomerNotes    *string        `json:"customer_notes"`
 	AdminNotes       *string        `json:"admin_notes"`
-	PaymentProof     *string        `json:"payment_proof"`
+	PaymentProof     *string        `json:"payment_proof"` // short-lived signed URL
+	PaymentProofID   *int64         `json:"-"`
 	ClientName       string         `json:"client_name"`
 	ClientPhone      string         `json:"client_phone"`
 	EbuyStoreName    *string        `json:"ebuy_store_name"`
@@ -75,6 +122,7 @@ type OrderItem struct {
 	OrderItemID     int64         `json:"order_item_id"`
 	OrderID         int64         `json:"order_id"`
 	ProductID       int64         `json:"product_id"`
+	VariantID       *int64        `json:"variant_id"`
 	Quantity        int           `json:"quantity"`
 	UnitPrice       float64       `json:"unit_price"`
 	DiscountAmount  float64       `json:"discount_amount"`
@@ -85,6 +133,11 @@ type OrderItem struct {
 	SizeType        *SizeType     `json:"size_type"`
 	IsFreeItem      bool          `json:"is_free_item"`
 	ParentDiscountID *int64       `json:"parent_discount_id"`
+	// BundleItemID is set on the component lines a bundle line expands into.
+	BundleItemID    *int64        `json:"bundle_item_id"`
+	// Customisations are the options chosen at checkout; their surcharges
+	// are included in UnitPrice.
+	Customisations  []Customisation `json:"customisations"`
 	ProductImage    *string       `json:"product_image"`
 }
 
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// PaymentProofStatus represents the review state of a payment proof.
+type PaymentProofStatus string
+
+const (
+	PaymentProofStatusSubmitted PaymentProofStatus = "submitted"
+	PaymentProofStatusApproved  PaymentProofStatus = "approved"
+	PaymentProofStatusRejected  PaymentProofStatus = "rejected"
+)
+
+// PaymentProof represents an uploaded payment screenshot awaiting review.
+// ProofPath is a key inside private storage and is never exposed directly.
+type PaymentProof struct {
+	ProofID       int64              `json:"proof_id"`
+	OrderID       int64              `json:"order_id"`
+	ClientID      int64              `json:"client_id"`
+	PaymentMethod PaymentMethod      `json:"payment_method"`
+	Amount        float64            `json:"amount"`
+	ProofPath     string             `json:"-"`
+	Status        PaymentProofStatus `json:"status"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
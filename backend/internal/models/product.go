@@ -1,6 +1,8 @@
 package models
 
 import (
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -10,8 +12,20 @@ type ProductType string
 const (
 	ProductTypeFaiachun ProductType = "faiachun"
 	ProductTypeBag      ProductType = "bag"
+	// ProductTypeBundle is sold at its own price and made up of other
+	// products; see BundleComponent.
+	ProductTypeBundle ProductType = "bundle"
 )
 
+// IsValid reports whether t matches product_type_enum.
+func (t ProductType) IsValid() bool {
+	switch t {
+	case ProductTypeFaiachun, ProductTypeBag, ProductTypeBundle:
+		return true
+	}
+	return false
+}
+
 // SizeType represents the available sizes for products.
 type SizeType string
 
@@ -22,31 +36,83 @@ const (
 	SizeTypeBigSquare SizeType = "big-square"
 )
 
+// IsValid reports whether s matches size_type_enum.
+func (s SizeType) IsValid() bool {
+	switch s {
+	case SizeTypeVRect, SizeTypeSquare, SizeTypeFatVRect, SizeTypeBigSquare:
+		return true
+	}
+	return false
+}
+
 // Product represents a product in the catalog.
 type Product struct {
 	ID             int64       `json:"product_id"`
 	Name           string      `json:"product_name"`
+	Slug           string      `json:"slug"`
 	Description    *string     `json:"product_description"`
 	Type           ProductType `json:"product_type"`
 	Hashtag        *string     `json:"hashtag"`
 	SKU            string      `json:"sku"`
 	Price          float64     `json:"price"`
 	CompareAtPrice *float64    `json:"compare_at_price"`
-	CostPrice      *float64    `json:"cost_price"`
+	CostPrice      *float64    `json:"-"` // staff only, see AdminProduct
 	Quantity       int         `json:"quantity"`
 	Weight         *float64    `json:"weight"`
 	Dimensions     *string     `json:"dimensions"` // JSONB stored as string
 	AvailableSizes []SizeType  `json:"available_sizes"`
 	IsFeatured     bool        `json:"is_featured"`
 	IsActive       bool        `json:"is_active"`
+	// PublishAt and UnpublishAt bound when an active product is listed.
+	PublishAt      *time.Time  `json:"publish_at"`
+	UnpublishAt    *time.Time  `json:"unpublish_at"`
 	SEOTitle       *string     `json:"seo_title"`
 	SEODescription *string     `json:"seo_description"`
 	Tags           *string     `json:"tags"` // JSONB stored as string
-	CreatedBy      *int64      `json:"created_by"`
+	CreatedBy      *int64      `json:"-"` // staff only, see AdminProduct
 	CreatedAt      time.Time   `json:"created_at"`
 	UpdatedAt      time.Time   `json:"updated_at"`
 }
 
+// IsListed reports whether the product is active and inside its publishing
+// window at now.
+func (p Product) IsListed(now time.Time) bool {
+	return p.IsActive &&
+		(p.PublishAt == nil || !p.PublishAt.After(now)) &&
+		(p.UnpublishAt == nil || p.UnpublishAt.After(now))
+}
+
+// ProductPath is the storefront page of a product: /product/{slug}, or
+// /product/{id} for a product without a slug.
+func ProductPath(productID int64, slug string) string {
+	if slug == "" {
+		return "/product/" + strconv.FormatInt(productID, 10)
+	}
+	return "/product/" + slug
+}
+
+// seoDescriptionLength caps the generated meta description, in characters.
+const seoDescriptionLength = 160
+
+// ApplySEODefaults fills an unset SEO title with the product name and an unset
+// SEO description with the start of the product description.
+func (p *Product) ApplySEODefaults() {
+	if p.SEOTitle == nil || strings.TrimSpace(*p.SEOTitle) == "" {
+		title := p.Name
+		p.SEOTitle = &title
+	}
+	if (p.SEODescription == nil || strings.TrimSpace(*p.SEODescription) == "") && p.Description != nil {
+		desc := []rune(strings.Join(strings.Fields(*p.Description), " "))
+		if len(desc) > seoDescriptionLength {
+			desc = append(desc[:seoDescriptionLength-1], '…')
+		}
+		if len(desc) > 0 {
+			text := string(desc)
+			p.SEODescription = &text
+		}
+	}
+}
+
 // ProductImage represents an image associated with a product.
 type ProductImage struct {
 	ID           int64     `json:"image_id"`
@@ -61,6 +127,62 @@ type ProductImage struct {
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// ProductVariant is a sellable size of a product with its own price and stock.
+type ProductVariant struct {
+	ID             int64     `json:"variant_id"`
+	ProductID      int64     `json:"product_id"`
+	SizeType       SizeType  `json:"size_type"`
+	SKUSuffix      string    `json:"sku_suffix"`
+	SKU            string    `json:"sku"` // product SKU + suffix
+	Price          float64   `json:"price"`
+	CompareAtPrice *float64  `json:"compare_at_price"`
+	Quantity       int       `json:"quantity"`
+	IsActive       bool      `json:"is_active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	// ListedCompareAtPrice is the stored compare-at price, shown to staff
+	// only. On product responses CompareAtPrice is cleared unless it is
+	// honest (see RecentPrices).
+	ListedCompareAtPrice *float64 `json:"-"`
+	LowestRecentPrice    *float64 `json:"lowest_recent_price"`
+}
+
+// BundleComponent is one product, in one size for products sold per size, that
+// a bundle is made of. Quantity is per bundle.
+type BundleComponent struct {
+	ProductID   int64       `json:"product_id"`
+	ProductName string      `json:"product_name"`
+	Slug        string      `json:"slug"`
+	ProductType ProductType `json:"product_type"`
+	SizeType    *SizeType   `json:"size_type"`
+	VariantID   *int64      `json:"variant_id"`
+	SKU         string      `json:"sku"`
+	Quantity    int         `json:"quantity"`
+	// Available reports whether the component can currently be sold: the
+	// product is active and, if sold per size, the variant is active.
+	Available bool `json:"available"`
+}
+
+// PriceHistoryDays is the window over which a compare-at price must have been
+// a selling price to be shown.
+const PriceHistoryDays = 30
+
+// RecentPrices are the lowest and highest prices a product or variant sold at
+// over the last PriceHistoryDays days, including its current price.
+type RecentPrices struct {
+	Lowest  float64
+	Highest float64
+}
+
+// CompareAt returns compareAt if it is above price and the item sold at that
+// price or higher within the window, and nil otherwise.
+func (r RecentPrices) CompareAt(price float64, compareAt *float64) *float64 {
+	if compareAt == nil || *compareAt <= price || r.Highest < *compareAt {
+		return nil
+	}
+	return compareAt
+}
+
 // Category represents a product category.
 type Category struct {
 	ID          int64  `json:"category_id"`
@@ -68,20 +190,170 @@ type Category struct {
 	Description *string `json:"category_description"`
 	ParentID    *int64 `json:"parent_category_id"`
 	IsActive    bool   `json:"is_active"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
 // ProductWithDetails represents a product with its images and categories.
 type ProductWithDetails struct {
 	Product
-	Images     []ProductImage `json:"images"`
-	Categories []Category     `json:"categories"`
+	Images     []ProductImage   `json:"images"`
+	Categories []Category       `json:"categories"`
+	Variants   []ProductVariant `json:"variants"`
+	// Components is only set on bundles, by the single-product lookup.
+	Components []BundleComponent `json:"components,omitempty"`
+	// CustomisationOptions is likewise only set by the single-product lookup.
+	CustomisationOptions []CustomisationOption `json:"customisation_options,omitempty"`
+	// RatingAverage is null until the product has an approved review.
+	RatingAverage *float64 `json:"rating_average"`
+	ReviewCount   int      `json:"review_count"`
+	// ListedCompareAtPrice and LowestRecentPrice work as on ProductVariant.
+	ListedCompareAtPrice *float64 `json:"-"`
+	LowestRecentPrice    *float64 `json:"lowest_recent_price"`
+	// Galleries holds, per available size, that size's images followed by
+	// size-agnostic ones.
+	Galleries map[SizeType][]ProductImage `json:"galleries"`
+	// Highlight is only set on search results.
+	Highlight *SearchHighlight `json:"highlight,omitempty"`
+}
+
+// AdminProduct is a product as staff see it, including the fields public
+// responses leave out.
+type AdminProduct struct {
+	*ProductWithDetails
+	CostPrice            *float64              `json:"cost_price"`
+	CreatedBy            *int64                `json:"created_by"`
+	ListedCompareAtPrice *float64              `json:"listed_compare_at_price"`
+	Variants             []AdminProductVariant `json:"variants"`
+}
+
+// NewAdminProduct wraps p for admin responses.
+func NewAdminProduct(p *ProductWithDetails) AdminProduct {
+	variants := make([]AdminProductVariant, len(p.Variants))
+	for i, v := range p.Variants {
+		variants[i] = NewAdminProductVariant(v)
+	}
+	return AdminProduct{
+		ProductWithDetails:   p,
+		CostPrice:            p.CostPrice,
+		CreatedBy:            p.CreatedBy,
+		ListedCompareAtPrice: p.ListedCompareAtPrice,
+		Variants:             variants,
+	}
+}
+
+// AdminProductVariant is a variant as staff see it.
+type AdminProductVariant struct {
+	ProductVariant
+	ListedCompareAtPrice *float64 `json:"listed_compare_at_price"`
+}
+
+// NewAdminProductVariant wraps v for admin responses.
+func NewAdminProductVariant(v ProductVariant) AdminProductVariant {
+	return AdminProductVariant{ProductVariant: v, ListedCompareAtPrice: v.ListedCompareAtPrice}
+}
+
+// SearchHighlight holds HTML-escaped text with matches wrapped in <mark>.
+type SearchHighlight struct {
+	ProductName string `json:"product_name"`
+	Snippet     string `json:"snippet,omitempty"`
+}
+
+// BuildGalleries groups Images into per-size galleries for each available size.
+func (p *ProductWithDetails) BuildGalleries() {
+	p.Galleries = make(map[SizeType][]ProductImage, len(p.AvailableSizes))
+	for _, size := range p.AvailableSizes {
+		gallery := []ProductImage{}
+		for _, img := range p.Images {
+			if img.SizeType != nil && *img.SizeType == size {
+				gallery = append(gallery, img)
+			}
+		}
+		for _, img := range p.Images {
+			if img.SizeType == nil {
+				gallery = append(gallery, img)
+			}
+		}
+		p.Galleries[size] = gallery
+	}
+}
+
+// RelatedProduct is a product recommended alongside another one.
+type RelatedProduct struct {
+	ID             int64       `json:"product_id"`
+	Name           string      `json:"product_name"`
+	Slug           string      `json:"slug"`
+	Type           ProductType `json:"product_type"`
+	Price          float64     `json:"price"`
+	CompareAtPrice *float64    `json:"compare_at_price"`
+	ImageURL       *string     `json:"image_url"`
+	ThumbnailURL   *string     `json:"thumbnail_url"`
+	// CoPurchaseCount is the number of orders containing both products.
+	CoPurchaseCount  int     `json:"co_purchase_count"`
+	SharedCategories int     `json:"shared_categories"`
+	SameHashtag      bool    `json:"same_hashtag"`
+	Score            float64 `json:"score"`
+}
+
+// SearchSuggestions is the autocomplete response for a search prefix.
+type SearchSuggestions struct {
+	Products   []ProductSuggestion  `json:"products"`
+	Hashtags   []string             `json:"hashtags"`
+	Categories []CategorySuggestion `json:"categories"`
+}
+
+// ProductSuggestion is a product whose name starts with the search prefix.
+type ProductSuggestion struct {
+	ID   int64  `json:"product_id"`
+	Name string `json:"product_name"`
+	Slug string `json:"slug"`
+}
+
+// CategorySuggestion is a category whose name starts with the search prefix.
+type CategorySuggestion struct {
+	ID   int64  `json:"category_id"`
+	Name string `json:"category_name"`
 }
 
 // ProductListResponse represents the paginated response for product listing.
 type ProductListResponse struct {
-	Data []ProductWithDetails `json:"data"`
-	Meta PaginationMeta       `json:"meta"`
+	Data   []ProductWithDetails `json:"data"`
+	Meta   PaginationMeta       `json:"meta"`
+	Facets *ProductFacets       `json:"facets,omitempty"`
+}
+
+// ProductFacets holds filter-chip counts for the current filter set. Each
+// dimension ignores its own filter, so selecting another value of that
+// dimension would return the listed count.
+type ProductFacets struct {
+	ProductTypes []FacetCount    `json:"product_types"`
+	Categories   []CategoryFacet `json:"categories"`
+	Sizes        []FacetCount    `json:"sizes"`
+	Hashtags     []FacetCount    `json:"hashtags"`
+	PriceBuckets []PriceBucket   `json:"price_buckets"`
+}
+
+// FacetCount is the number of matching products for one facet value.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// CategoryFacet counts matching products in a category or any descendant.
+type CategoryFacet struct {
+	ID       int64  `json:"category_id"`
+	Name     string `json:"category_name"`
+	ParentID *int64 `json:"parent_category_id"`
+	Count    int    `json:"count"`
+}
+
+// PriceBucket counts matching products priced in [Min, Max). Max is nil for
+// the open-ended top bucket.
+type PriceBucket struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int      `json:"count"`
 }
 
 // PaginationMeta contains pagination information.
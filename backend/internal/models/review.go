@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// ReviewStatus is the moderation state of a product review.
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "pending"
+	ReviewStatusApproved ReviewStatus = "approved"
+	ReviewStatusRejected ReviewStatus = "rejected"
+)
+
+// IsValid reports whether s is a known review status.
+func (s ReviewStatus) IsValid() bool {
+	switch s {
+	case ReviewStatusPending, ReviewStatusApproved, ReviewStatusRejected:
+		return true
+	}
+	return false
+}
+
+// ProductReview is a rating left by a client who received the product.
+type ProductReview struct {
+	ID        int64  `json:"review_id"`
+	ProductID int64  `json:"product_id"`
+	ClientID  int64  `json:"client_id"`
+	OrderID   *int64 `json:"order_id,omitempty"`
+	// ReviewerName is the client's username, if they set one.
+	ReviewerName *string      `json:"reviewer_name"`
+	Rating       int          `json:"rating"`
+	Body         *string      `json:"body"`
+	PhotoURL     *string      `json:"photo_url"`
+	Status       ReviewStatus `json:"status"`
+	ModeratedAt  *time.Time   `json:"moderated_at,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// AdminProductReview is a review with the product it belongs to, for moderation.
+type AdminProductReview struct {
+	ProductReview
+	ProductName string  `json:"product_name"`
+	ClientPhone *string `json:"client_phone"`
+}
+
+// ProductRating summarises the approved reviews of a product.
+type ProductRating struct {
+	Average *float64 `json:"rating_average"`
+	Count   int      `json:"review_count"`
+}
+
+// ProductReviewListResponse is a page of a product's approved reviews.
+type ProductReviewListResponse struct {
+	Data   []ProductReview `json:"data"`
+	Meta   PaginationMeta  `json:"meta"`
+	Rating ProductRating   `json:"rating"`
+}
+
+// AdminReviewListResponse is a page of the moderation queue.
+type AdminReviewListResponse struct {
+	Data []AdminProductReview `json:"data"`
+	Meta PaginationMeta       `json:"meta"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// ShippingRate is a shipping price rule. A rate applies to one shipping
+// method, optionally one region, and shipments weighing from MinWeight up to
+// (not including) MaxWeight kg.
+type ShippingRate struct {
+	ID     int64          `json:"rate_id"`
+	Name   string         `json:"rate_name"`
+	Method ShippingMethod `json:"shipping_method"`
+	// Region matches a delivery address's city, state or country, ignoring
+	// case; nil matches everywhere.
+	Region    *string  `json:"region"`
+	MinWeight float64  `json:"min_weight"`
+	MaxWeight *float64 `json:"max_weight"`
+	BaseFee   float64  `json:"base_fee"`
+	FeePerKg  float64  `json:"fee_per_kg"`
+	// FreeOver waives the fee for orders whose discounted subtotal is at
+	// least this much.
+	FreeOver  *float64  `json:"free_over"`
+	Priority  int       `json:"priority"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Charge returns the fee for a shipment of weight kg, before free-shipping
+// thresholds and promotions, rounded to cents.
+func (r ShippingRate) Charge(weight float64) float64 {
+	return math.Round((r.BaseFee+r.FeePerKg*weight)*100) / 100
+}
+
+// IsFreeFor reports whether an order with this discounted subtotal ships free.
+func (r ShippingRate) IsFreeFor(subtotal float64) bool {
+	return r.FreeOver != nil && subtotal >= *r.FreeOver
+}
+
+// ShippingQuote is the shipping price of a cart.
+type ShippingQuote struct {
+	Method             ShippingMethod `json:"shipping_method"`
+	RateID             int64          `json:"rate_id"`
+	RateName           string         `json:"rate_name"`
+	Weight             float64        `json:"weight"`
+	Subtotal           float64        `json:"subtotal"`
+	DiscountedSubtotal float64        `json:"discounted_subtotal"`
+	// ShippingFee is the rate's charge; DiscountedShippingFee is what is
+	// paid after free-shipping thresholds and promotions.
+	ShippingFee           float64  `json:"shipping_fee"`
+	DiscountedShippingFee float64  `json:"discounted_shipping_fee"`
+	FreeOver              *float64 `json:"free_over"`
+	// AmountToFreeShipping is how much more would make the order ship free
+	// under the rate's threshold; nil when there is none or it is reached.
+	AmountToFreeShipping *float64 `json:"amount_to_free_shipping"`
+}
+
+// ShippingRegions returns the region names an address matches: its city,
+// state and country, lowercased.
+func ShippingRegions(city, state, country string) []string {
+	var regions []string
+	for _, r := range []string{city, state, country} {
+		if r = strings.ToLower(strings.TrimSpace(r)); r != "" {
+			regions = append(regions, r)
+		}
+	}
+	return regions
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxSlugLength bounds generated slugs so URLs stay readable.
+const MaxSlugLength = 80
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// IsValidSlug reports whether s is a lowercase, hyphen-separated slug.
+func IsValidSlug(s string) bool {
+	return len(s) <= MaxSlugLength && slugPattern.MatchString(s)
+}
+
+// Slugify turns a product name into a URL slug. Latin letters and digits are
+// kept with accents stripped, Chinese characters become their toneless pinyin
+// syllables and everything else separates words, so "FaiAchun 2025 家宅平安"
+// becomes "faiachun-2025-jia-zhai-ping-an". The result is empty when nothing
+// in s can be transliterated; callers fall back to the SKU.
+func Slugify(s string) string {
+	args := pinyin.NewArgs()
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining accent split off by NFD; keep the base letter's word going.
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(unicode.ToLower(r))
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if syllables := pinyin.SinglePinyin(r, args); len(syllables) > 0 {
+				words = append(words, syllables[0])
+			}
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	slug := strings.Join(words, "-")
+	if len(slug) > MaxSlugLength {
+		slug = strings.TrimRight(slug[:MaxSlugLength], "-")
+		if i := strings.LastIndexByte(slug, '-'); i > MaxSlugLength/2 {
+			slug = slug[:i]
+		}
+	}
+	return slug
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// StockAlertChannel is how a client is told a product is back in stock.
+type StockAlertChannel string
+
+const (
+	StockAlertChannelSMS   StockAlertChannel = "sms"
+	StockAlertChannelEmail StockAlertChannel = "email"
+)
+
+// IsValid reports whether c is a known alert channel.
+func (c StockAlertChannel) IsValid() bool {
+	return c == StockAlertChannelSMS || c == StockAlertChannelEmail
+}
+
+// StockSubscription is a client's request to hear when a product, or one of
+// its sizes, is back in stock.
+type StockSubscription struct {
+	ID          int64             `json:"subscription_id"`
+	ProductID   int64             `json:"product_id"`
+	ProductName string            `json:"product_name"`
+	SizeType    *SizeType         `json:"size_type"`
+	Channel     StockAlertChannel `json:"channel"`
+	NotifiedAt  *time.Time        `json:"notified_at"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// StockAlert is a claimed subscription ready to be sent.
+type StockAlert struct {
+	SubscriptionID int64
+	ProductID      int64
+	Slug           string
+	ProductName    string
+	SizeType       *SizeType
+	Channel        StockAlertChannel
+	Phone          *string
+	Email          *string
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// WishlistItem is a product a client saved, with its current price and stock.
+// Price, CompareAtPrice and StockQuantity come from the size's variant when
+// the product is sold per size; without a size they are the product's price
+// and the stock across its active sizes.
+type WishlistItem struct {
+	ID             int64       `json:"wishlist_item_id"`
+	ProductID      int64       `json:"product_id"`
+	Slug           string      `json:"slug"`
+	ProductName    string      `json:"product_name"`
+	ProductType    ProductType `json:"product_type"`
+	SizeType       *SizeType   `json:"size_type"`
+	VariantID      *int64      `json:"variant_id"`
+	Price          float64     `json:"price"`
+	CompareAtPrice *float64    `json:"compare_at_price"`
+	StockQuantity  int         `json:"stock_quantity"`
+	InStock        bool        `json:"in_stock"`
+	ThumbnailURL   string      `json:"thumbnail_url"`
+	AddedAt        time.Time   `json:"added_at"`
+}
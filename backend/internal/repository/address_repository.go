@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// ErrAddressInUse is returned when deleting an address that an open order
+// ships to.
+var ErrAddressInUse = errors.New("address is used by an open order")
+
+// AddressRepository handles database operations for client address books.
+type AddressRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAddressRepository(db *pgxpool.Pool) *AddressRepository {
+	return &AddressRepository{db: db}
+}
+
+// AddressParams holds address fields; nil fields are left unchanged on
+// update. An empty AddressLine2 or Phone clears it.
+type AddressParams struct {
+	AddressType  *string
+	AddressLine1 *string
+	AddressLine2 *string
+	City         *string
+	State        *string
+	PostalCode   *string
+	Country      *string
+	Phone        *string
+	IsDefault    *bool
+}
+
+// openOrderStatuses are the order statuses that still need the address.
+const openOrderStatuses = `('pending', 'confirmed', 'processing', 'shipped')`
+
+const addressColumns = `
+	address_id, client_id, COALESCE(address_type, 'home'), address_line1, address_line2,
+	city, state, postal_code, country, phone, COALESCE(is_default, false), created_at, updated_at`
+
+func scanAddress(row pgx.Row) (*models.ClientAddress, error) {
+	var a models.ClientAddress
+	if err := row.Scan(
+		&a.ID, &a.ClientID, &a.AddressType, &a.AddressLine1, &a.AddressLine2,
+		&a.City, &a.State, &a.PostalCode, &a.Country, &a.Phone, &a.IsDefault, &a.CreatedAt, &a.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan address: %w", err)
+	}
+	return &a, nil
+}
+
+// List returns a client's addresses, default first then newest. defaultOnly
+// limits it to the default address.
+func (r *AddressRepository) List(ctx context.Context, clientID int64, defaultOnly bool) ([]models.ClientAddress, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT`+addressColumns+`
+		FROM client_address
+		WHERE client_id = $1 AND archived_at IS NULL AND (NOT $2 OR is_default)
+		ORDER BY is_default DESC NULLS LAST, created_at DESC, address_id DESC`, clientID, defaultOnly)
+	if err != nil {
+		return nil, fmt.Errorf("query addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := []models.ClientAddress{}
+	for rows.Next() {
+		a, err := scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return addresses, nil
+}
+
+// Get returns one of a client's addresses.
+func (r *AddressRepository) Get(ctx context.Context, clientID, addressID int64) (*models.ClientAddress, error) {
+	return scanAddress(r.db.QueryRow(ctx, `
+		SELECT`+addressColumns+`
+		FROM client_address
+		WHERE address_id = $1 AND client_id = $2 AND archived_at IS NULL`, addressID, clientID))
+}
+
+// lockAddressBook serialises address book changes of a client, so that
+// demoting the previous default and setting a new one cannot interleave.
+func lockAddressBook(ctx context.Context, tx pgx.Tx, clientID int64) error {
+	var id int64
+	err := tx.QueryRow(ctx, `SELECT client_id FROM client WHERE client_id = $1 FOR UPDATE`, clientID).Scan(&id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrNotFound
+		}
+		return fmt.Errorf("lock client: %w", err)
+	}
+	return nil
+}
+
+// demoteDefault clears the default flag on the client's other addresses.
+func demoteDefault(ctx context.Context, tx pgx.Tx, clientID, keepAddressID int64) error {
+	if _, err := tx.Exec(ctx, `
+		UPDATE client_address SET is_default = false
+		WHERE client_id = $1 AND is_default AND address_id <> $2`, clientID, keepAddressID); err != nil {
+		return fmt.Errorf("demote default address: %w", err)
+	}
+	return nil
+}
+
+// Create adds an address. It becomes the default when IsDefault is set or
+// it is the client's first address.
+func (r *AddressRepository) Create(ctx context.Context, clientID int64, params AddressParams) (*models.ClientAddress, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockAddressBook(ctx, tx, clientID); err != nil {
+		return nil, err
+	}
+
+	var hasAddress bool
+	if err := tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM client_address WHERE client_id = $1 AND archived_at IS NULL)`,
+		clientID).Scan(&hasAddress); err != nil {
+		return nil, fmt.Errorf("count addresses: %w", err)
+	}
+	isDefault := !hasAddress || (params.IsDefault != nil && *params.IsDefault)
+	if isDefault {
+		if err := demoteDefault(ctx, tx, clientID, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	addressType := "home"
+	if params.AddressType != nil {
+		addressType = *params.AddressType
+	}
+	a, err := scanAddress(tx.QueryRow(ctx, `
+		INSERT INTO client_address (
+			client_id, address_type, address_line1, address_line2, city, state, postal_code, country, phone, is_default
+		) VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, NULLIF($9, ''), $10)
+		RETURNING`+addressColumns,
+		clientID, addressType, deref(params.AddressLine1), deref(params.AddressLine2), deref(params.City),
+		deref(params.State), deref(params.PostalCode), deref(params.Country), deref(params.Phone), isDefault))
+	if err != nil {
+		return nil, fmt.Errorf("insert address: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Update changes an address. Setting IsDefault demotes the previous default.
+func (r *AddressRepository) Update(ctx context.Context, clientID, addressID int64, params AddressParams) (*models.ClientAddress, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockAddressBook(ctx, tx, clientID); err != nil {
+		return nil, err
+	}
+
+	var sets []string
+	var args []interface{}
+	add := func(col string, val interface{}) {
+		args = append(args, val)
+		sets = append(sets, fmt.Sprintf("%s = $%d", col, len(args)))
+	}
+	addNullable := func(col string, val string) {
+		args = append(args, val)
+		sets = append(sets, fmt.Sprintf("%s = NULLIF($%d, '')", col, len(args)))
+	}
+
+	if params.AddressType != nil {
+		add("address_type", *params.AddressType)
+	}
+	if params.AddressLine1 != nil {
+		add("address_line1", *params.AddressLine1)
+	}
+	if params.AddressLine2 != nil {
+		addNullable("address_line2", *params.AddressLine2)
+	}
+	if params.City != nil {
+		add("city", *params.City)
+	}
+	if params.State != nil {
+		add("state", *params.State)
+	}
+	if params.PostalCode != nil {
+		add("postal_code", *params.PostalCode)
+	}
+	if params.Country != nil {
+		add("country", *params.Country)
+	}
+	if params.Phone != nil {
+		addNullable("phone", *params.Phone)
+	}
+	if params.IsDefault != nil {
+		if *params.IsDefault {
+			if err := demoteDefault(ctx, tx, clientID, addressID); err != nil {
+				return nil, err
+			}
+		}
+		add("is_default", *params.IsDefault)
+	}
+
+	if len(sets) == 0 {
+		// Nothing to change; still report a missing address.
+		sets = append(sets, "address_id = address_id")
+	}
+
+	args = append(args, addressID, clientID)
+	a, err := scanAddress(tx.QueryRow(ctx, fmt.Sprintf(`
+		UPDATE client_address SET %s
+		WHERE address_id = $%d AND client_id = $%d AND archived_at IS NULL
+		RETURNING`+addressColumns, strings.Join(sets, ", "), len(args)-1, len(args)), args...))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Delete removes an address. Addresses an open order ships to give
+// ErrAddressInUse; ones only past orders used are archived instead, as
+// orders keep referencing them.
+func (r *AddressRepository) Delete(ctx context.Context, clientID, addressID int64) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockAddressBook(ctx, tx, clientID); err != nil {
+		return err
+	}
+
+	var exists, openOrders, anyOrders bool
+	err = tx.QueryRow(ctx, `
+		SELECT true,
+		       EXISTS (SELECT 1 FROM orders WHERE shipping_address_id = a.address_id AND order_status IN `+openOrderStatuses+`),
+		       EXISTS (SELECT 1 FROM orders WHERE shipping_address_id = a.address_id)
+		FROM client_address a
+		WHERE a.address_id = $1 AND a.client_id = $2 AND a.archived_at IS NULL`,
+		addressID, clientID).Scan(&exists, &openOrders, &anyOrders)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrNotFound
+		}
+		return fmt.Errorf("check address orders: %w", err)
+	}
+	if openOrders {
+		return ErrAddressInUse
+	}
+
+	if anyOrders {
+		_, err = tx.Exec(ctx, `
+			UPDATE client_address SET archived_at = NOW(), is_default = false
+			WHERE address_id = $1`, addressID)
+	} else {
+		_, err = tx.Exec(ctx, `DELETE FROM client_address WHERE address_id = $1`, addressID)
+	}
+	if err != nil {
+		return fmt.Errorf("delete address: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// deref returns *s, or "" for nil.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
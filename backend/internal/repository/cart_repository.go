@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -144,17 +145,25 @@ func (r *CartRepository) RemoveDiscountFromCart(ctx context.Context, cartID stri
 // GetCartItems retrieves items in a cart.
 func (r *CartRepository) GetCartItems(ctx context.Context, cartID string) ([]models.CartItemResponse, error) {
 	query := `
-		SELECT ci.cart_item_id, ci.product_id, ci.size_type, ci.quantity, ci.added_at,
-		       p.product_name, p.product_type, p.price, p.quantity as stock_quantity,
+		SELECT ci.cart_item_id, ci.product_id, v.variant_id, ci.size_type, ci.quantity, ci.added_at, ci.customisations,
+		       p.product_name, p.product_type,
+		       COALESCE(v.price, p.price) as unit_price,
+		       CASE WHEN p.product_type = 'bundle' THEN ` + bundleStockSQL("p") + `
+		       ELSE COALESCE(v.quantity, p.quantity) END as stock_quantity,
 		       COALESCE(img.thumbnail_path, img.image_path, '') as thumbnail_url
 		FROM cart_items ci
 		JOIN products p ON ci.product_id = p.product_id
+		LEFT JOIN product_variants v ON v.product_id = ci.product_id AND v.size_type = ci.size_type
 		LEFT JOIN LATERAL (
 			SELECT thumbnail_path, image_path
 			FROM product_images pi
 			WHERE pi.product_id = ci.product_id
 			ORDER BY 
-				CASE WHEN ci.size_type IS NOT NULL AND pi.size_type = ci.size_type THEN 0 ELSE 1 END,
+				CASE
+					WHEN ci.size_type IS NOT NULL AND pi.size_type = ci.size_type THEN 0
+					WHEN pi.size_type IS NULL THEN 1
+					ELSE 2
+				END,
 				pi.is_primary DESC, 
 				pi.sort_order ASC
 			LIMIT 1
@@ -169,11 +178,14 @@ func (r *CartRepository) GetCartItems(ctx context.Context, cartID string) ([]mod
 	defer rows.Close()
 
 	var items []models.CartItemResponse
+	var values []map[string]string
+	var productIDs []int64
 	for rows.Next() {
 		var item models.CartItemResponse
 		var sizeType *models.SizeType
 		var thumbnailURL string
-		err := rows.Scan(&item.CartItemID, &item.ProductID, &sizeType, &item.Quantity, &item.AddedAt,
+		var itemValues map[string]string
+		err := rows.Scan(&item.CartItemID, &item.ProductID, &item.VariantID, &sizeType, &item.Quantity, &item.AddedAt, &itemValues,
 			&item.ProductName, &item.ProductType, &item.UnitPrice, &item.StockQuantity, &thumbnailURL)
 		if err != nil {
 			return nil, err
@@ -181,20 +193,101 @@ func (r *CartRepository) GetCartItems(ctx context.Context, cartID string) ([]mod
 		item.SizeType = sizeType
 		item.ThumbnailURL = thumbnailURL
 		items = append(items, item)
+		values = append(values, itemValues)
+		productIDs = append(productIDs, item.ProductID)
 	}
-	return items, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	options, err := getCustomisationOptions(ctx, r.db, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		chosen, surcharge, err := models.ApplyCustomisations(options[items[i].ProductID], values[i])
+		if err != nil {
+			// The options changed since the item was added; show the values
+			// as entered and let checkout reject them.
+			keys := make([]string, 0, len(values[i]))
+			for key := range values[i] {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			chosen = []models.Customisation{}
+			for _, key := range keys {
+				chosen = append(chosen, models.Customisation{Key: key, Label: key, Value: values[i][key]})
+			}
+			surcharge = 0
+		}
+		items[i].Customisations = chosen
+		items[i].UnitPrice += surcharge
+	}
+	return items, nil
+}
+
+// normaliseCustomisations checks customisation values against the product's
+// options and returns them trimmed, without empty values, so that equal
+// choices share a cart line.
+func normaliseCustomisations(ctx context.Context, db rowQuerier, productID int64, values map[string]string) (map[string]string, error) {
+	options, err := getCustomisationOptions(ctx, db, []int64{productID})
+	if err != nil {
+		return nil, err
+	}
+	chosen, _, err := models.ApplyCustomisations(options[productID], values)
+	if err != nil {
+		return nil, err
+	}
+	normalised := make(map[string]string, len(chosen))
+	for _, c := range chosen {
+		normalised[c.Key] = c.Value
+	}
+	return normalised, nil
 }
 
-// AddItemToCart adds or updates an item in the cart.
-func (r *CartRepository) AddItemToCart(ctx context.Context, cartID string, productID int64, sizeType *models.SizeType, quantity int) error {
+// AddItemToCart adds or updates an item in the cart. Sizes sold as variants
+// are checked against the variant's stock, including units already in the cart.
+// Customisation values are checked against the product's options and returned
+// as a *models.CustomisationError when invalid; items with different values
+// are kept as separate lines. The check and the write run in one transaction
+// holding the cart's lock, so concurrent adds cannot overshoot the stock.
+func (r *CartRepository) AddItemToCart(ctx context.Context, cartID string, productID int64, sizeType *models.SizeType, customisations map[string]string, quantity int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := lockCart(ctx, tx, cartID); err != nil {
+		return err
+	}
+
+	var inCart int
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(quantity), 0) FROM cart_items
+		WHERE cart_id = $1 AND product_id = $2 AND size_type IS NOT DISTINCT FROM $3`,
+		cartID, productID, sizeType).Scan(&inCart)
+	if err != nil {
+		return err
+	}
+	if err := checkVariantStock(ctx, tx, productID, sizeType, inCart+quantity); err != nil {
+		return err
+	}
+	values, err := normaliseCustomisations(ctx, tx, productID, customisations)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO cart_items (cart_id, product_id, size_type, quantity)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (cart_id, product_id, size_type)
+		INSERT INTO cart_items (cart_id, product_id, size_type, customisations, quantity)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (cart_id, product_id, size_type, customisations)
 		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity, added_at = CURRENT_TIMESTAMP
 	`
-	_, err := r.db.Exec(ctx, query, cartID, productID, sizeType, quantity)
-	return err
+	if _, err := tx.Exec(ctx, query, cartID, productID, sizeType, values, quantity); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 // UpdateCartItem updates the quantity of an item.
@@ -202,13 +295,107 @@ func (r *CartRepository) UpdateCartItem(ctx context.Context, cartItemID int64, q
 	if quantity <= 0 {
 		return r.RemoveCartItem(ctx, cartItemID)
 	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	cartID, err := r.GetCartIDByCartItemID(ctx, cartItemID)
+	if err != nil {
+		return err
+	}
+	if err := lockCart(ctx, tx, cartID); err != nil {
+		return err
+	}
+
+	// Lines of the same size with other customisations share its stock.
+	var productID int64
+	var sizeType *models.SizeType
+	var otherLines int
+	err = tx.QueryRow(ctx, `
+		SELECT ci.product_id, ci.size_type,
+		       (SELECT COALESCE(SUM(o.quantity), 0) FROM cart_items o
+		        WHERE o.cart_id = ci.cart_id AND o.product_id = ci.product_id
+		          AND o.size_type IS NOT DISTINCT FROM ci.size_type AND o.cart_item_id <> ci.cart_item_id)
+		FROM cart_items ci WHERE ci.cart_item_id = $1`, cartItemID).Scan(&productID, &sizeType, &otherLines)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("cart item not found")
+		}
+		return err
+	}
+	if err := checkVariantStock(ctx, tx, productID, sizeType, otherLines+quantity); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE cart_items
 		SET quantity = $2, added_at = CURRENT_TIMESTAMP
 		WHERE cart_item_id = $1
 	`
-	_, err := r.db.Exec(ctx, query, cartItemID, quantity)
-	return err
+	if _, err := tx.Exec(ctx, query, cartItemID, quantity); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// lockCart locks a cart row for the rest of tx, serialising writes to its
+// items.
+func lockCart(ctx context.Context, tx pgx.Tx, cartID string) error {
+	var locked string
+	err := tx.QueryRow(ctx, `SELECT cart_id FROM cart WHERE cart_id = $1 FOR UPDATE`, cartID).Scan(&locked)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("cart not found")
+		}
+		return fmt.Errorf("lock cart: %w", err)
+	}
+	return nil
+}
+
+// checkVariantStock verifies that quantity units of a product size can be
+// bought. Products without variants are checked against products.quantity
+// and bundles against their components' stock. Stock is reserved at
+// checkout, not here.
+func checkVariantStock(ctx context.Context, db rowQuerier, productID int64, sizeType *models.SizeType, quantity int) error {
+	var productType models.ProductType
+	var listed, hasVariants, isActive bool
+	var productStock int
+	var stock *int
+	err := db.QueryRow(ctx, `
+		SELECT p.product_type, COALESCE(p.is_active, false) AND `+publishedCond("p")+`,
+			EXISTS (SELECT 1 FROM product_variants WHERE product_id = p.product_id),
+			COALESCE(p.quantity, 0), v.quantity, COALESCE(v.is_active, false)
+		FROM products p
+		LEFT JOIN product_variants v ON v.product_id = p.product_id AND v.size_type = $2::size_type_enum
+		WHERE p.product_id = $1`,
+		productID, sizeType).Scan(&productType, &listed, &hasVariants, &productStock, &stock, &isActive)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	}
+	if !listed {
+		return ErrProductUnavailable
+	}
+	if productType == models.ProductTypeBundle {
+		if sizeType != nil {
+			return ErrVariantUnavailable
+		}
+		return checkBundleStock(ctx, db, productID, quantity)
+	}
+	if !hasVariants {
+		stock, isActive = &productStock, true
+	}
+	if stock == nil || !isActive {
+		return ErrVariantUnavailable
+	}
+	if quantity > *stock {
+		return ErrInsufficientStock
+	}
+	return nil
 }
 
 // RemoveCartItem removes an item from the cart.
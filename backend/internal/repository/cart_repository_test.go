@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+func TestCheckVariantStock(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewProductRepository(pool)
+
+	vRect, square, bigSquare := models.SizeTypeVRect, models.SizeTypeSquare, models.SizeTypeBigSquare
+	faiachun := createTestProduct(t, repo, "FC-1", models.ProductTypeFaiachun, 20, 5, vRect, square)
+	bag := createTestProduct(t, repo, "BAG-1", models.ProductTypeBag, 50, 3)
+
+	// 5 units over two sizes: the first gets the odd one.
+	stock := variantStock(t, pool, faiachun)
+	if stock[vRect] != 3 || stock[square] != 2 {
+		t.Fatalf("variant stock = %v, want v-rect 3, square 2", stock)
+	}
+
+	inactive := false
+	if _, err := repo.UpsertProductVariant(ctx, faiachun, square, ProductVariantParams{IsActive: &inactive}); err != nil {
+		t.Fatalf("UpsertProductVariant: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		productID int64
+		sizeType  *models.SizeType
+		quantity  int
+		want      error
+	}{
+		{"size in stock", faiachun, &vRect, 3, nil},
+		{"size short", faiachun, &vRect, 4, ErrInsufficientStock},
+		{"inactive size", faiachun, &square, 1, ErrVariantUnavailable},
+		{"size not offered", faiachun, &bigSquare, 1, ErrVariantUnavailable},
+		{"no size given", faiachun, nil, 1, ErrVariantUnavailable},
+		{"product without sizes", bag, nil, 3, nil},
+		{"product without sizes short", bag, nil, 4, ErrInsufficientStock},
+		{"missing product", bag + 1000, nil, 1, ErrNotFound},
+	}
+	for _, tt := range tests {
+		if err := checkVariantStock(ctx, pool, tt.productID, tt.sizeType, tt.quantity); err != tt.want {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// ErrCategoryCycle is returned when a parent assignment would make a category
+// its own ancestor.
+var ErrCategoryCycle = errors.New("category cannot be its own ancestor")
+
+// ErrCategoryHasChildren is returned when deleting a category that still has subcategories.
+var ErrCategoryHasChildren = errors.New("category has subcategories")
+
+// CategoryRepository handles database operations for product categories.
+type CategoryRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCategoryRepository(db *pgxpool.Pool) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+// ListCategories returns all categories with product counts that include
+// descendant categories. With activeOnly, categories that are inactive or
+// outside their publishing window and everything below them are left out.
+// Counts only include listed products.
+func (r *CategoryRepository) ListCategories(ctx context.Context, activeOnly bool) ([]models.CategoryNode, error) {
+	query := `
+		WITH RECURSIVE visible AS (
+			SELECT category_id FROM categories
+			WHERE parent_category_id IS NULL AND (NOT $1 OR (COALESCE(is_active, true) AND `+publishedCond("categories")+`))
+			UNION ALL
+			SELECT c.category_id FROM categories c
+			JOIN visible v ON c.parent_category_id = v.category_id
+			WHERE NOT $1 OR (COALESCE(c.is_active, true) AND `+publishedCond("c")+`)
+		), subtree AS (
+			SELECT category_id AS root_id, category_id FROM categories
+			UNION ALL
+			SELECT s.root_id, c.category_id FROM categories c
+			JOIN subtree s ON c.parent_category_id = s.category_id
+		)
+		SELECT c.category_id, c.category_name, c.category_description, c.parent_category_id,
+		       COALESCE(c.is_active, true), c.publish_at, c.unpublish_at, c.created_at,
+		       (SELECT COUNT(DISTINCT pc.product_id)
+		        FROM subtree s
+		        JOIN product_categories pc ON pc.category_id = s.category_id
+		        JOIN products p ON p.product_id = pc.product_id AND p.is_active AND `+publishedCond("p")+`
+		        WHERE s.root_id = c.category_id) AS product_count
+		FROM categories c
+		JOIN visible v ON v.category_id = c.category_id
+		ORDER BY c.category_name, c.category_id`
+
+	rows, err := r.db.Query(ctx, query, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("query categories: %w", err)
+	}
+	defer rows.Close()
+
+	nodes := []models.CategoryNode{}
+	for rows.Next() {
+		var n models.CategoryNode
+		if err := rows.Scan(
+			&n.ID, &n.Name, &n.Description, &n.ParentID, &n.IsActive, &n.PublishAt, &n.UnpublishAt, &n.CreatedAt, &n.ProductCount,
+		); err != nil {
+			return nil, fmt.Errorf("scan category: %w", err)
+		}
+		nodes = append(nodes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return nodes, nil
+}
+
+// GetCategoryByID retrieves a single category.
+func (r *CategoryRepository) GetCategoryByID(ctx context.Context, categoryID int64) (*models.Category, error) {
+	var cat models.Category
+	err := r.db.QueryRow(ctx, `
+		SELECT category_id, category_name, category_description, parent_category_id, COALESCE(is_active, true),
+		       publish_at, unpublish_at, created_at
+		FROM categories
+		WHERE category_id = $1`, categoryID,
+	).Scan(&cat.ID, &cat.Name, &cat.Description, &cat.ParentID, &cat.IsActive, &cat.PublishAt, &cat.UnpublishAt, &cat.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("query category: %w", err)
+	}
+	return &cat, nil
+}
+
+// CategoryParams holds writable category fields. On update, nil fields are
+// left unchanged; ClearParent moves the category to the top level.
+type CategoryParams struct {
+	Name        *string
+	Description *string
+	ParentID    *int64
+	ClearParent bool
+	IsActive    *bool
+	PublishWindow
+}
+
+// CreateCategory inserts a category and returns its ID.
+func (r *CategoryRepository) CreateCategory(ctx context.Context, params CategoryParams) (int64, error) {
+	if params.ParentID != nil {
+		if _, err := r.GetCategoryByID(ctx, *params.ParentID); err != nil {
+			return 0, err
+		}
+	}
+
+	isActive := true
+	if params.IsActive != nil {
+		isActive = *params.IsActive
+	}
+
+	var id int64
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO categories (category_name, category_description, parent_category_id, is_active, publish_at, unpublish_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING category_id`,
+		params.Name, params.Description, params.ParentID, isActive, params.PublishAt, params.UnpublishAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("insert category: %w", err)
+	}
+	return id, nil
+}
+
+// UpdateCategory applies a partial update. A new parent is rejected with
+// ErrCategoryCycle when it is the category itself or one of its descendants.
+func (r *CategoryRepository) UpdateCategory(ctx context.Context, categoryID int64, params CategoryParams) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialise tree changes so two concurrent moves cannot form a cycle.
+	if _, err := tx.Exec(ctx, `LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("lock categories: %w", err)
+	}
+
+	var sets []string
+	var args []interface{}
+	add := func(col string, val interface{}) {
+		args = append(args, val)
+		sets = append(sets, fmt.Sprintf("%s = $%d", col, len(args)))
+	}
+
+	if params.Name != nil {
+		add("category_name", *params.Name)
+	}
+	if params.Description != nil {
+		add("category_description", *params.Description)
+	}
+	if params.IsActive != nil {
+		add("is_active", *params.IsActive)
+	}
+	params.PublishWindow.addColumns(add)
+	if params.ClearParent {
+		add("parent_category_id", nil)
+	} else if params.ParentID != nil {
+		var exists, cycle bool
+		err := tx.QueryRow(ctx, `
+			WITH RECURSIVE ancestors AS (
+				SELECT category_id, parent_category_id FROM categories WHERE category_id = $1
+				UNION
+				SELECT c.category_id, c.parent_category_id FROM categories c
+				JOIN ancestors a ON c.category_id = a.parent_category_id
+			)
+			SELECT EXISTS (SELECT 1 FROM ancestors),
+			       EXISTS (SELECT 1 FROM ancestors WHERE category_id = $2)`,
+			*params.ParentID, categoryID,
+		).Scan(&exists, &cycle)
+		if err != nil {
+			return fmt.Errorf("check category ancestry: %w", err)
+		}
+		if !exists {
+			return ErrNotFound
+		}
+		if cycle {
+			return ErrCategoryCycle
+		}
+		add("parent_category_id", *params.ParentID)
+	}
+
+	if len(sets) == 0 {
+		// Nothing to change; still report a missing category.
+		sets = append(sets, "category_id = category_id")
+	}
+
+	args = append(args, categoryID)
+	cmd, err := tx.Exec(ctx, fmt.Sprintf(`UPDATE categories SET %s WHERE category_id = $%d`,
+		strings.Join(sets, ", "), len(args)), args...)
+	if err != nil {
+		return fmt.Errorf("update category: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return tx.Commit(ctx)
+}
+
+// DeleteCategory removes a leaf category; its product mappings cascade.
+func (r *CategoryRepository) DeleteCategory(ctx context.Context, categoryID int64) error {
+	var hasChildren bool
+	if err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM categories WHERE parent_category_id = $1)`, categoryID,
+	).Scan(&hasChildren); err != nil {
+		return fmt.Errorf("check subcategories: %w", err)
+	}
+	if hasChildren {
+		return ErrCategoryHasChildren
+	}
+
+	cmd, err := r.db.Exec(ctx, `DELETE FROM categories WHERE category_id = $1`, categoryID)
+	if err != nil {
+		return fmt.Errorf("delete category: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
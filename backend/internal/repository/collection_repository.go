@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// CollectionRepository handles database operations for product collections.
+type CollectionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCollectionRepository(db *pgxpool.Pool) *CollectionRepository {
+	return &CollectionRepository{db: db}
+}
+
+// collectionQuery selects collections with the number of listed products.
+var collectionQuery = `
+	SELECT c.collection_id, c.collection_name, c.slug, c.collection_description, c.sort_order,
+	       c.is_active, c.publish_at, c.unpublish_at,
+	       (SELECT COUNT(*)::int
+	        FROM collection_products cp
+	        JOIN products p ON p.product_id = cp.product_id
+	        WHERE cp.collection_id = c.collection_id AND p.is_active AND ` + publishedCond("p") + `),
+	       c.created_at, c.updated_at
+	FROM collections c`
+
+func scanCollection(row pgx.Row) (*models.Collection, error) {
+	var col models.Collection
+	if err := row.Scan(
+		&col.ID, &col.Name, &col.Slug, &col.Description, &col.SortOrder,
+		&col.IsActive, &col.PublishAt, &col.UnpublishAt, &col.ProductCount,
+		&col.CreatedAt, &col.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan collection: %w", err)
+	}
+	return &col, nil
+}
+
+// List returns collections by sort_order, then name. With listedOnly,
+// collections that are inactive or outside their publishing window are left
+// out.
+func (r *CollectionRepository) List(ctx context.Context, listedOnly bool) ([]models.Collection, error) {
+	rows, err := r.db.Query(ctx, collectionQuery+`
+		WHERE NOT $1 OR (c.is_active AND `+publishedCond("c")+`)
+		ORDER BY c.sort_order, c.collection_name, c.collection_id`, listedOnly)
+	if err != nil {
+		return nil, fmt.Errorf("query collections: %w", err)
+	}
+	defer rows.Close()
+
+	collections := []models.Collection{}
+	for rows.Next() {
+		col, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, *col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return collections, nil
+}
+
+// GetByID retrieves a collection whether or not it is listed.
+func (r *CollectionRepository) GetByID(ctx context.Context, collectionID int64) (*models.Collection, error) {
+	return scanCollection(r.db.QueryRow(ctx, collectionQuery+`
+		WHERE c.collection_id = $1`, collectionID))
+}
+
+// GetListedBySlug retrieves an active collection inside its publishing window.
+func (r *CollectionRepository) GetListedBySlug(ctx context.Context, slug string) (*models.Collection, error) {
+	return scanCollection(r.db.QueryRow(ctx, collectionQuery+`
+		WHERE c.slug = $1 AND c.is_active AND `+publishedCond("c"), slug))
+}
+
+// CollectionParams holds writable collection fields. Nil fields are left
+// unchanged on update; on insert they default to sort order 0 and active.
+type CollectionParams struct {
+	Name        *string
+	Slug        *string
+	Description *string
+	SortOrder   *int
+	IsActive    *bool
+	PublishWindow
+}
+
+// Create inserts a collection and returns its ID. Name and slug are required;
+// a taken slug fails with a unique violation.
+func (r *CollectionRepository) Create(ctx context.Context, params CollectionParams) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO collections (collection_name, slug, collection_description, sort_order, is_active, publish_at, unpublish_at)
+		VALUES ($1, $2, $3, COALESCE($4::int, 0), COALESCE($5::boolean, true), $6, $7)
+		RETURNING collection_id`,
+		params.Name, params.Slug, params.Description, params.SortOrder, params.IsActive,
+		params.PublishAt, params.UnpublishAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("insert collection: %w", err)
+	}
+	return id, nil
+}
+
+// Update applies a partial update to a collection.
+func (r *CollectionRepository) Update(ctx context.Context, collectionID int64, params CollectionParams) error {
+	var sets []string
+	var args []interface{}
+	add := func(col string, val interface{}) {
+		args = append(args, val)
+		sets = append(sets, fmt.Sprintf("%s = $%d", col, len(args)))
+	}
+
+	if params.Name != nil {
+		add("collection_name", *params.Name)
+	}
+	if params.Slug != nil {
+		add("slug", *params.Slug)
+	}
+	if params.Description != nil {
+		add("collection_description", *params.Description)
+	}
+	if params.SortOrder != nil {
+		add("sort_order", *params.SortOrder)
+	}
+	if params.IsActive != nil {
+		add("is_active", *params.IsActive)
+	}
+	params.PublishWindow.addColumns(add)
+
+	if len(sets) == 0 {
+		// Nothing to change; still report a missing collection.
+		sets = append(sets, "collection_id = collection_id")
+	}
+
+	args = append(args, collectionID)
+	cmd, err := r.db.Exec(ctx, fmt.Sprintf(`UPDATE collections SET %s WHERE collection_id = $%d`,
+		strings.Join(sets, ", "), len(args)), args...)
+	if err != nil {
+		return fmt.Errorf("update collection: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a collection; its product list cascades.
+func (r *CollectionRepository) Delete(ctx context.Context, collectionID int64) error {
+	cmd, err := r.db.Exec(ctx, `DELETE FROM collections WHERE collection_id = $1`, collectionID)
+	if err != nil {
+		return fmt.Errorf("delete collection: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetProducts replaces a collection's products; their order in productIDs is
+// the order on the landing page. Unknown product IDs yield ErrNotFound.
+func (r *CollectionRepository) SetProducts(ctx context.Context, collectionID int64, productIDs []int64) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM collection_products WHERE collection_id = $1`, collectionID); err != nil {
+		return fmt.Errorf("clear collection products: %w", err)
+	}
+	cmd, err := tx.Exec(ctx, `
+		INSERT INTO collection_products (collection_id, product_id, position)
+		SELECT $1, p.product_id, ids.ord::int
+		FROM unnest($2::bigint[]) WITH ORDINALITY AS ids(product_id, ord)
+		JOIN products p ON p.product_id = ids.product_id`,
+		collectionID, productIDs)
+	if err != nil {
+		return fmt.Errorf("insert collection products: %w", err)
+	}
+	if int(cmd.RowsAffected()) != len(productIDs) {
+		return ErrNotFound
+	}
+	return tx.Commit(ctx)
+}
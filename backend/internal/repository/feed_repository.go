@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// FeedRepository reads the catalogue for sitemap and merchant feed generation.
+type FeedRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFeedRepository(db *pgxpool.Pool) *FeedRepository {
+	return &FeedRepository{db: db}
+}
+
+// ListSitemapProducts returns every listed product (active and inside its
+// publishing window), most recently updated first.
+func (r *FeedRepository) ListSitemapProducts(ctx context.Context) ([]models.SitemapProduct, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT product_id, COALESCE(slug, ''), COALESCE(updated_at, created_at)
+		FROM products
+		WHERE is_active AND `+publishedCond("products")+`
+		ORDER BY updated_at DESC NULLS LAST, product_id`)
+	if err != nil {
+		return nil, fmt.Errorf("query sitemap products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []models.SitemapProduct{}
+	for rows.Next() {
+		var p models.SitemapProduct
+		if err := rows.Scan(&p.ID, &p.Slug, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan sitemap product: %w", err)
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// ListSitemapCategories returns listed categories whose ancestors are all
+// listed. LastMod is the newest of the category's creation and the updates of
+// listed products in it or any descendant.
+func (r *FeedRepository) ListSitemapCategories(ctx context.Context) ([]models.SitemapCategory, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH RECURSIVE visible AS (
+			SELECT category_id FROM categories
+			WHERE parent_category_id IS NULL AND COALESCE(is_active, true) AND `+publishedCond("categories")+`
+			UNION ALL
+			SELECT c.category_id FROM categories c
+			JOIN visible v ON c.parent_category_id = v.category_id
+			WHERE COALESCE(c.is_active, true) AND `+publishedCond("c")+`
+		), subtree AS (
+			SELECT category_id AS root_id, category_id FROM categories
+			UNION ALL
+			SELECT s.root_id, c.category_id FROM categories c
+			JOIN subtree s ON c.parent_category_id = s.category_id
+		)
+		SELECT c.category_id,
+		       COALESCE(GREATEST(c.created_at, (
+		           SELECT MAX(p.updated_at)
+		           FROM subtree s
+		           JOIN product_categories pc ON pc.category_id = s.category_id
+		           JOIN products p ON p.product_id = pc.product_id AND p.is_active AND `+publishedCond("p")+`
+		           WHERE s.root_id = c.category_id)), CURRENT_TIMESTAMP)
+		FROM categories c
+		JOIN visible v ON v.category_id = c.category_id
+		ORDER BY c.category_id`)
+	if err != nil {
+		return nil, fmt.Errorf("query sitemap categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []models.SitemapCategory{}
+	for rows.Next() {
+		var c models.SitemapCategory
+		if err := rows.Scan(&c.ID, &c.LastMod); err != nil {
+			return nil, fmt.Errorf("scan sitemap category: %w", err)
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+// ListFeedItems returns the merchant feed items for active products: one per
+// active variant, or one for the product itself when it is not sold per size.
+// Products whose variants are all inactive are left out.
+func (r *FeedRepository) ListFeedItems(ctx context.Context) ([]models.FeedItem, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT p.product_id, COALESCE(p.slug, ''), p.sku, p.product_name,
+		       COALESCE(p.product_description, ''), p.product_type, v.size_type,
+		       COALESCE(v.price, p.price),
+		       CASE WHEN v.variant_id IS NULL THEN p.compare_at_price ELSE v.compare_at_price END,
+		       CASE WHEN p.product_type = 'bundle' THEN `+bundleStockSQL("p")+`
+		            ELSE GREATEST(COALESCE(v.quantity, p.quantity, 0), 0) END,
+		       p.sku || COALESCE(v.sku_suffix, ''), COALESCE(v.variant_id, 0)::bigint
+		FROM products p
+		LEFT JOIN product_variants v ON v.product_id = p.product_id AND COALESCE(v.is_active, true)
+		WHERE p.is_active AND `+publishedCond("p")+`
+		  AND (v.variant_id IS NOT NULL
+		       OR NOT EXISTS (SELECT 1 FROM product_variants pv WHERE pv.product_id = p.product_id))
+		ORDER BY p.product_id, v.size_type`)
+	if err != nil {
+		return nil, fmt.Errorf("query feed items: %w", err)
+	}
+
+	items := []models.FeedItem{}
+	keys := []priceKey{}
+	productIDs := []int64{}
+	for rows.Next() {
+		var item models.FeedItem
+		var productSKU string
+		var variantID int64
+		if err := rows.Scan(
+			&item.ProductID, &item.Slug, &productSKU, &item.Title,
+			&item.Description, &item.Type, &item.SizeType,
+			&item.Price, &item.CompareAtPrice, &item.Quantity,
+			&item.ID, &variantID,
+		); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan feed item: %w", err)
+		}
+		if item.SizeType != nil {
+			item.GroupID = productSKU
+		}
+		if len(productIDs) == 0 || productIDs[len(productIDs)-1] != item.ProductID {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		items = append(items, item)
+		keys = append(keys, priceKey{item.ProductID, variantID})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	images, err := r.imagesByProductIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := getRecentPrices(ctx, r.db, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].ImageURLs = feedImageURLs(images[items[i].ProductID], items[i].SizeType)
+		items[i].CompareAtPrice = prices[keys[i]].CompareAt(items[i].Price, items[i].CompareAtPrice)
+	}
+	return items, nil
+}
+
+// imagesByProductIDs loads product images ordered primary first, then by sort order.
+func (r *FeedRepository) imagesByProductIDs(ctx context.Context, productIDs []int64) (map[int64][]models.ProductImage, error) {
+	images := make(map[int64][]models.ProductImage)
+	if len(productIDs) == 0 {
+		return images, nil
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT product_id, image_path, size_type
+		FROM product_images
+		WHERE product_id = ANY($1)
+		ORDER BY product_id, COALESCE(is_primary, false) DESC, sort_order, image_id`, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query feed images: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var img models.ProductImage
+		if err := rows.Scan(&img.ProductID, &img.URL, &img.SizeType); err != nil {
+			return nil, fmt.Errorf("scan feed image: %w", err)
+		}
+		images[img.ProductID] = append(images[img.ProductID], img)
+	}
+	return images, rows.Err()
+}
+
+// feedImageURLs orders a product's images for one item the way carts pick
+// thumbnails: images of the item's size, then size-agnostic ones, then the rest.
+func feedImageURLs(images []models.ProductImage, size *models.SizeType) []string {
+	rank := func(img models.ProductImage) int {
+		switch {
+		case size == nil:
+			return 0
+		case img.SizeType != nil && *img.SizeType == *size:
+			return 0
+		case img.SizeType == nil:
+			return 1
+		default:
+			return 2
+		}
+	}
+
+	urls := make([]string, 0, len(images))
+	for want := 0; want <= 2; want++ {
+		for _, img := range images {
+			if rank(img) == want {
+				urls = append(urls, img.URL)
+			}
+		}
+	}
+	return urls
+}
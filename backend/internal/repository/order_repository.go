@@ -6,13 +6,17 @@ import (
 	"sort"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/ryangel/ryangel-backend/internal/models"
 )
 
+// CreateOrderParams describes a checkout. Exactly one of EbuyStoreID (pickup)
+// and ShippingAddressID (home delivery) is set.
 type CreateOrderParams struct {
 	ClientID    int64
 	EbuyStoreID string
+	ShippingAddressID *int64
 	Name        string
 	Phone       string // Contact phone for this order
 	Email       string
@@ -52,11 +56,38 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, params CreateOrderPar
 		return nil, fmt.Errorf("cart not found or empty")
 	}
 
+	// Home delivery ships to one of the client's saved addresses; the order
+	// keeps a copy of it.
+	shippingMethod := models.ShippingMethodPickup
+	var shippingAddress *models.AddressSnapshot
+	if params.ShippingAddressID != nil {
+		shippingMethod = models.ShippingMethodDelivery
+		var a models.AddressSnapshot
+		err := tx.QueryRow(ctx, `
+			SELECT COALESCE(address_type, 'home'), address_line1, address_line2, city, state, postal_code, country, phone
+			FROM client_address
+			WHERE address_id = $1 AND client_id = $2 AND archived_at IS NULL
+			FOR SHARE`, *params.ShippingAddressID, params.ClientID).Scan(
+			&a.AddressType, &a.AddressLine1, &a.AddressLine2, &a.City, &a.State, &a.PostalCode, &a.Country, &a.Phone)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil, ErrNotFound
+			}
+			return nil, err
+		}
+		shippingAddress = &a
+	}
+
 	// 2. Get Cart Items
+	// Sizes sold as variants take their price, SKU and stock from the variant.
 	queryItems := `
-		SELECT ci.product_id, ci.size_type, ci.quantity, p.price, p.product_name, p.product_type, p.sku
+		SELECT ci.product_id, v.variant_id, ci.size_type, ci.quantity, ci.customisations,
+		       COALESCE(v.price, p.price), p.product_name, p.product_type, p.sku || COALESCE(v.sku_suffix, ''),
+		       EXISTS (SELECT 1 FROM product_variants WHERE product_id = ci.product_id) AS has_variants,
+		       COALESCE(v.is_active, false), COALESCE(p.is_active, false) AND `+publishedCond("p")+`
 		FROM cart_items ci
 		JOIN products p ON ci.product_id = p.product_id
+		LEFT JOIN product_variants v ON v.product_id = ci.product_id AND v.size_type = ci.size_type
 		WHERE ci.cart_id = $1
 	`
 	rows, err := tx.Query(ctx, queryItems, cartID)
@@ -66,24 +97,36 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, params CreateOrderPar
 	
 	type cartItem struct {
 		ProductID   int64
+		VariantID   *int64
 		SizeType    *string
 		Quantity    int
 		Price       float64
 		ProductName string
 		ProductType string
 		SKU         string
+		Values         map[string]string
+		Customisations []models.Customisation
 	}
 	var items []cartItem
 	var subtotal float64
 	
 	for rows.Next() {
 		var i cartItem
-		if err := rows.Scan(&i.ProductID, &i.SizeType, &i.Quantity, &i.Price, &i.ProductName, &i.ProductType, &i.SKU); err != nil {
+		var hasVariants, variantActive, listed bool
+		if err := rows.Scan(&i.ProductID, &i.VariantID, &i.SizeType, &i.Quantity, &i.Values, &i.Price, &i.ProductName, &i.ProductType, &i.SKU,
+			&hasVariants, &variantActive, &listed); err != nil {
 			rows.Close()
 			return nil, err
 		}
+		if !listed {
+			rows.Close()
+			return nil, ErrProductUnavailable
+		}
+		if hasVariants && (i.VariantID == nil || !variantActive) {
+			rows.Close()
+			return nil, ErrVariantUnavailable
+		}
 		items = append(items, i)
-		subtotal += i.Price * float64(i.Quantity)
 	}
 	rows.Close()
 
@@ -91,9 +134,68 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, params CreateOrderPar
 		return nil, fmt.Errorf("cart is empty")
 	}
 
+	// Check customisations against the current options and price them in.
+	productIDs := make([]int64, 0, len(items))
+	for _, i := range items {
+		productIDs = append(productIDs, i.ProductID)
+	}
+	options, err := getCustomisationOptions(ctx, tx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for k := range items {
+		chosen, surcharge, err := models.ApplyCustomisations(options[items[k].ProductID], items[k].Values)
+		if err != nil {
+			return nil, err
+		}
+		items[k].Customisations = chosen
+		items[k].Price += surcharge
+		subtotal += items[k].Price * float64(items[k].Quantity)
+	}
+
+	// Bundles are fulfilled, and take stock, as their components.
+	var bundleIDs []int64
+	for _, i := range items {
+		if i.ProductType == string(models.ProductTypeBundle) {
+			bundleIDs = append(bundleIDs, i.ProductID)
+		}
+	}
+	var bundles map[int64][]bundleComponent
+	if len(bundleIDs) > 0 {
+		bundles, err = getBundleComponents(ctx, tx, bundleIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range bundleIDs {
+			if len(bundles[id]) == 0 {
+				return nil, ErrBundleUnavailable
+			}
+			for _, bc := range bundles[id] {
+				if !bc.Available {
+					return nil, ErrBundleUnavailable
+				}
+			}
+		}
+	}
+
+	// Price shipping by the method's rate for the cart weight and, for
+	// delivery, the address's region.
+	weight, err := cartWeight(ctx, tx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	var regions []string
+	if shippingAddress != nil {
+		regions = models.ShippingRegions(shippingAddress.City, shippingAddress.State, shippingAddress.Country)
+	}
+	rate, err := findShippingRate(ctx, tx, shippingMethod, regions, weight)
+	if err != nil {
+		return nil, err
+	}
+
 	// Calculate Discounts & Shipping (Replicating logic from CartHandler)
 	itemDiscountAmount := 0.0
-	finalShippingFee := 5.0
+	finalShippingFee := rate.Charge(weight)
 
 	// Fetch active auto-apply discounts within the transaction
 	discountQuery := `
@@ -194,16 +296,13 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, params CreateOrderPar
 	// Generate Order Number: ORD-YYYYMMDD-Random
 	orderNum := fmt.Sprintf("ORD-%s-%d", time.Now().Format("20060102"), time.Now().Unix()%100000)
 
-	customerNotes := fmt.Sprintf("Store: %s\nContact: %s\nIG: %s\nEmail: %s", params.EbuyStoreID, params.Name, params.Instagram, params.Email)
-
-	shippingAmount := finalShippingFee
-	discountAmount := itemDiscountAmount
-	// Ensure non-negative
-	if discountAmount > subtotal {
-		discountAmount = subtotal
+	destination := "Store: " + params.EbuyStoreID
+	if shippingAddress != nil {
+		destination = "Delivery: " + shippingAddress.SingleLine()
 	}
-	
-	totalAmount := subtotal - discountAmount + shippingAmount
+	customerNotes := fmt.Sprintf("%s\nContact: %s\nIG: %s\nEmail: %s", destination, params.Name, params.Instagram, params.Email)
+
+	discountAmount, shippingAmount, totalAmount := orderTotals(subtotal, itemDiscountAmount, finalShippingFee, rate)
 
 	var orderID int64
 	var orderDate time.Time
@@ -211,14 +310,17 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, params CreateOrderPar
 		INSERT INTO orders (
 			order_number, client_id, order_status, 
 			subtotal_amount, discount_amount, shipping_amount, tax_amount, total_amount,
-			ebuy_store_id, payment_method, payment_status, customer_notes, order_date, contact_phone
+			ebuy_store_id, payment_method, payment_status, customer_notes, order_date, contact_phone,
+			shipping_address_id, shipping_address
 		) VALUES (
 			$1, $2, 'pending', 
 			$3, $4, $5, 0, $6, 
-			$7, 'mpay', 'pending', $8, NOW(), $9
+			NULLIF($7, ''), 'mpay', 'pending', $8, NOW(), $9,
+			$10, $11
 		) RETURNING order_id, order_date`,
 		orderNum, params.ClientID, subtotal, discountAmount, shippingAmount, totalAmount,
 		params.EbuyStoreID, customerNotes, params.Phone,
+		params.ShippingAddressID, shippingAddress,
 	).Scan(&orderID, &orderDate)
 	if err != nil {
 		return nil, err
@@ -228,22 +330,46 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, params CreateOrderPar
 	_, err = tx.Prepare(ctx, "insert_order_item", `
 		INSERT INTO order_items (
 			order_id, product_id, quantity, unit_price, discount_amount, total_price,
-			product_name, product_type, product_sku, size_type
-		) VALUES ($1, $2, $3, $4, 0, $5, $6, $7, $8, $9)
+			product_name, product_type, product_sku, size_type, variant_id, bundle_item_id, customisations
+		) VALUES ($1, $2, $3, $4, 0, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING order_item_id
 	`)
 	if err != nil {
 		return nil, err
 	}
-	
+
+	// Reserve stock line by line; bundles take theirs from the components.
 	for _, i := range items {
+		if i.ProductType != string(models.ProductTypeBundle) {
+			if err := reserveStock(ctx, tx, i.ProductID, i.VariantID, i.Quantity); err != nil {
+				return nil, err
+			}
+		}
+
 		totalPrice := i.Price * float64(i.Quantity)
-		_, err := tx.Exec(ctx, "insert_order_item", 
+		var itemID int64
+		err := tx.QueryRow(ctx, "insert_order_item", 
 			orderID, i.ProductID, i.Quantity, i.Price, totalPrice,
-			i.ProductName, i.ProductType, i.SKU, i.SizeType,
-		)
+			i.ProductName, i.ProductType, i.SKU, i.SizeType, i.VariantID, nil, i.Customisations,
+		).Scan(&itemID)
 		if err != nil {
 			return nil, err
 		}
+
+		// Expand bundles into zero-priced component lines for fulfilment.
+		for _, bc := range bundles[i.ProductID] {
+			quantity := bc.Quantity * i.Quantity
+			if err := reserveStock(ctx, tx, bc.ProductID, bc.VariantID, quantity); err != nil {
+				return nil, err
+			}
+			_, err := tx.Exec(ctx, "insert_order_item",
+				orderID, bc.ProductID, quantity, 0, 0,
+				bc.ProductName, bc.ProductType, bc.SKU, bc.SizeType, bc.VariantID, itemID, []models.Customisation{},
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// 6. Handle Payment Proof
@@ -269,15 +395,71 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, params CreateOrderPar
 		return nil, err
 	}
 
+	var ebuyStoreID *string
+	if params.EbuyStoreID != "" {
+		ebuyStoreID = &params.EbuyStoreID
+	}
 	return &models.Order{
 		OrderID:     orderID,
 		OrderNumber: orderNum,
-		TotalAmount: subtotal,
+		TotalAmount: totalAmount,
+		ShippingAmount: shippingAmount,
+		ShippingAddressID: params.ShippingAddressID,
+		EbuyStoreID: ebuyStoreID,
+		ShippingMethod: shippingMethod,
+		ShippingAddress: shippingAddress,
 		OrderDate:   orderDate,
 		OrderStatus: models.OrderStatusPending,
 	}, nil
 }
 
+// orderTotals works out an order's discount, shipping and total amounts. The
+// discount is capped at the subtotal, so the total never goes negative, and
+// shipping is waived once the discounted subtotal reaches the rate's
+// free-shipping threshold.
+func orderTotals(subtotal, discount, shipping float64, rate *models.ShippingRate) (float64, float64, float64) {
+	if discount > subtotal {
+		discount = subtotal
+	}
+	if rate.IsFreeFor(subtotal - discount) {
+		shipping = 0
+	}
+	return discount, shipping, subtotal - discount + shipping
+}
+
+// reserveStock takes quantity units from a line's stock (see stockKey). The
+// row lock serialises concurrent checkouts, and stock never goes negative.
+func reserveStock(ctx context.Context, tx pgx.Tx, productID int64, variantID *int64, quantity int) error {
+	query, id := `
+		UPDATE products SET quantity = quantity - $2
+		WHERE product_id = $1 AND quantity >= $2`, productID
+	if variantID != nil {
+		query, id = `
+		UPDATE product_variants SET quantity = quantity - $2
+		WHERE variant_id = $1 AND quantity >= $2`, *variantID
+	}
+	cmd, err := tx.Exec(ctx, query, id, quantity)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// orderStockSQL selects an order's reserved stock as (variant_id, product_id,
+// quantity) rows: variant lines by variant, and lines of products not sold
+// per size by product. Bundle lines hold none; their components do.
+const orderStockSQL = `
+	SELECT oi.variant_id, CASE WHEN oi.variant_id IS NULL THEN oi.product_id END AS product_id,
+	       SUM(oi.quantity) AS quantity
+	FROM order_items oi
+	WHERE oi.order_id = $1 AND oi.product_type <> 'bundle'
+	  AND (oi.variant_id IS NOT NULL
+	       OR NOT EXISTS (SELECT 1 FROM product_variants pv WHERE pv.product_id = oi.product_id))
+	GROUP BY 1, 2`
+
 func (r *OrderRepository) GetByClientID(ctx context.Context, clientID int64) ([]*models.Order, error) {
 	const query = `
 		SELECT o.order_id, o.order_number, o.client_id, o.order_status, o.subtotal_amount, 
@@ -286,7 +468,8 @@ func (r *OrderRepository) GetByClientID(ctx context.Context, clientID int64) ([]
                o.payment_method, o.payment_status, o.payment_reference, o.tracking_number,
                o.shipping_carrier, o.order_date, o.confirmed_at, o.shipped_at, o.delivered_at,
                o.cancelled_at, o.customer_notes, o.admin_notes, COALESCE(o.contact_phone, ''),
-			   (SELECT proof_path FROM payment_proofs WHERE order_id = o.order_id ORDER BY created_at DESC LIMIT 1) as payment_proof,
+               CASE WHEN o.shipping_address_id IS NOT NULL THEN 'home_delivery' ELSE 'ebuy_pickup' END, o.shipping_address,
+			   (SELECT proof_id FROM payment_proofs WHERE order_id = o.order_id ORDER BY created_at DESC LIMIT 1) as payment_proof_id,
 			   s.store_name
 		FROM orders o
         LEFT JOIN ebuy_store s ON o.ebuy_store_id = s.store_id
@@ -308,7 +491,8 @@ func (r *OrderRepository) GetByClientID(ctx context.Context, clientID int64) ([]
             &o.DiscountID, &o.DiscountCode, &o.ShippingAddressID, &o.EbuyStoreID,
             &o.PaymentMethod, &o.PaymentStatus, &o.PaymentReference, &o.TrackingNumber,
             &o.ShippingCarrier, &o.OrderDate, &o.ConfirmedAt, &o.ShippedAt, &o.DeliveredAt,
-            &o.CancelledAt, &o.CustomerNotes, &o.AdminNotes, &o.ContactPhone, &o.PaymentProof,
+            &o.CancelledAt, &o.CustomerNotes, &o.AdminNotes, &o.ContactPhone,
+            &o.ShippingMethod, &o.ShippingAddress, &o.PaymentProofID,
             &o.EbuyStoreName,
 		); err != nil {
 			return nil, err
@@ -349,7 +533,8 @@ func (r *OrderRepository) GetOrders(ctx context.Context, limit, offset int) ([]*
                o.payment_method, o.payment_status, o.payment_reference, o.tracking_number,
                o.shipping_carrier, o.order_date, o.confirmed_at, o.shipped_at, o.delivered_at,
                o.cancelled_at, o.customer_notes, o.admin_notes, COALESCE(o.contact_phone, ''),
-			   (SELECT proof_path FROM payment_proofs WHERE order_id = o.order_id ORDER BY created_at DESC LIMIT 1) as payment_proof,
+               CASE WHEN o.shipping_address_id IS NOT NULL THEN 'home_delivery' ELSE 'ebuy_pickup' END, o.shipping_address,
+			   (SELECT proof_id FROM payment_proofs WHERE order_id = o.order_id ORDER BY created_at DESC LIMIT 1) as payment_proof_id,
 			   c.username, c.phone, s.store_name
 		FROM orders o
 		JOIN client c ON o.client_id = c.client_id
@@ -372,7 +557,8 @@ func (r *OrderRepository) GetOrders(ctx context.Context, limit, offset int) ([]*
             &o.DiscountID, &o.DiscountCode, &o.ShippingAddressID, &o.EbuyStoreID,
             &o.PaymentMethod, &o.PaymentStatus, &o.PaymentReference, &o.TrackingNumber,
             &o.ShippingCarrier, &o.OrderDate, &o.ConfirmedAt, &o.ShippedAt, &o.DeliveredAt,
-            &o.CancelledAt, &o.CustomerNotes, &o.AdminNotes, &o.ContactPhone, &o.PaymentProof,
+            &o.CancelledAt, &o.CustomerNotes, &o.AdminNotes, &o.ContactPhone,
+            &o.ShippingMethod, &o.ShippingAddress, &o.PaymentProofID,
 			&o.ClientName, &o.ClientPhone, &o.EbuyStoreName,
 		); err != nil {
 			return nil, err
@@ -384,12 +570,22 @@ func (r *OrderRepository) GetOrders(ctx context.Context, limit, offset int) ([]*
 
 func (r *OrderRepository) GetOrderItems(ctx context.Context, orderID int64) ([]models.OrderItem, error) {
 	const query = `
-		SELECT oi.order_item_id, oi.order_id, oi.product_id, oi.quantity, oi.unit_price, 
+		SELECT oi.order_item_id, oi.order_id, oi.product_id, oi.variant_id, oi.quantity, oi.unit_price, 
                oi.discount_amount, oi.total_price, oi.product_name, oi.product_type, oi.product_sku,
-               oi.size_type, oi.is_free_item, oi.parent_discount_id,
-			   (SELECT image_path FROM product_images WHERE product_id = oi.product_id AND is_primary = true LIMIT 1) as product_image
+               oi.size_type, oi.is_free_item, oi.parent_discount_id, oi.bundle_item_id, oi.customisations,
+			   (SELECT pi.image_path FROM product_images pi
+			    WHERE pi.product_id = oi.product_id
+			    ORDER BY
+			        CASE
+			            WHEN oi.size_type IS NOT NULL AND pi.size_type = oi.size_type THEN 0
+			            WHEN pi.size_type IS NULL THEN 1
+			            ELSE 2
+			        END,
+			        pi.is_primary DESC, pi.sort_order ASC
+			    LIMIT 1) as product_image
 		FROM order_items oi
-		WHERE oi.order_id = $1`
+		WHERE oi.order_id = $1
+		ORDER BY oi.order_item_id`
 
 	rows, err := r.db.Query(ctx, query, orderID)
 	if err != nil {
@@ -401,9 +597,9 @@ func (r *OrderRepository) GetOrderItems(ctx context.Context, orderID int64) ([]m
 	for rows.Next() {
 		var i models.OrderItem
 		if err := rows.Scan(
-			&i.OrderItemID, &i.OrderID, &i.ProductID, &i.Quantity, &i.UnitPrice,
+			&i.OrderItemID, &i.OrderID, &i.ProductID, &i.VariantID, &i.Quantity, &i.UnitPrice,
             &i.DiscountAmount, &i.TotalPrice, &i.ProductName, &i.ProductType, &i.ProductSKU,
-            &i.SizeType, &i.IsFreeItem, &i.ParentDiscountID, &i.ProductImage,
+            &i.SizeType, &i.IsFreeItem, &i.ParentDiscountID, &i.BundleItemID, &i.Customisations, &i.ProductImage,
 		); err != nil {
 			return nil, err
 		}
@@ -412,6 +608,9 @@ func (r *OrderRepository) GetOrderItems(ctx context.Context, orderID int64) ([]m
 	return items, rows.Err()
 }
 
+// UpdateStatus sets an order's status. Cancelling returns its stock;
+// moving a cancelled order to another status reserves the stock again, or
+// fails with ErrInsufficientStock when it has since been sold.
 func (r *OrderRepository) UpdateStatus(ctx context.Context, orderID int64, status models.OrderStatus) error {
 	var query string
 	switch status {
@@ -422,11 +621,108 @@ func (r *OrderRepository) UpdateStatus(ctx context.Context, orderID int64, statu
 	case models.OrderStatusDelivered:
 		query = `UPDATE orders SET order_status = $1, delivered_at = COALESCE(delivered_at, NOW()) WHERE order_id = $2`
 	case models.OrderStatusCancelled:
-		query = `UPDATE orders SET order_status = $1, cancelled_at = COALESCE(cancelled_at, NOW()) WHERE order_id = $2`
+		return r.cancelOrder(ctx, orderID)
 	default:
 		query = `UPDATE orders SET order_status = $1 WHERE order_id = $2`
 	}
 
-	_, err := r.db.Exec(ctx, query, status, orderID)
-	return err
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var previous models.OrderStatus
+	err = tx.QueryRow(ctx, `SELECT order_status FROM orders WHERE order_id = $1 FOR UPDATE`, orderID).Scan(&previous)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	}
+	if previous == models.OrderStatusCancelled {
+		if err := reserveOrderStock(ctx, tx, orderID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, query, status, orderID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// reserveOrderStock takes an order's stock again. Like checkout, stock is
+// only decremented while it has the units, so it never goes negative; if any
+// is short the order gets ErrInsufficientStock.
+func reserveOrderStock(ctx context.Context, tx pgx.Tx, orderID int64) error {
+	var needed, reserved int
+	err := tx.QueryRow(ctx, `
+		WITH needed AS (`+orderStockSQL+`
+		), reserved_variants AS (
+			UPDATE product_variants v
+			SET quantity = v.quantity - n.quantity
+			FROM needed n
+			WHERE v.variant_id = n.variant_id AND v.quantity >= n.quantity
+			RETURNING v.variant_id
+		), reserved_products AS (
+			UPDATE products p
+			SET quantity = p.quantity - n.quantity
+			FROM needed n
+			WHERE p.product_id = n.product_id AND p.quantity >= n.quantity
+			RETURNING p.product_id
+		)
+		SELECT (SELECT COUNT(*) FROM needed),
+		       (SELECT COUNT(*) FROM reserved_variants) + (SELECT COUNT(*) FROM reserved_products)`, orderID).Scan(&needed, &reserved)
+	if err != nil {
+		return fmt.Errorf("reserve order stock: %w", err)
+	}
+	if reserved != needed {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// cancelOrder marks an order cancelled and returns its reserved stock.
+// Stock is only returned on the first cancellation.
+func (r *OrderRepository) cancelOrder(ctx context.Context, orderID int64) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var previous models.OrderStatus
+	err = tx.QueryRow(ctx, `SELECT order_status FROM orders WHERE order_id = $1 FOR UPDATE`, orderID).Scan(&previous)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	}
+	if previous == models.OrderStatusCancelled {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE orders SET order_status = $1, cancelled_at = COALESCE(cancelled_at, NOW())
+		WHERE order_id = $2`, models.OrderStatusCancelled, orderID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		WITH returned AS (`+orderStockSQL+`
+		), restocked_variants AS (
+			UPDATE product_variants v
+			SET quantity = v.quantity + r.quantity
+			FROM returned r
+			WHERE v.variant_id = r.variant_id
+		)
+		UPDATE products p
+		SET quantity = COALESCE(p.quantity, 0) + r.quantity
+		FROM returned r
+		WHERE p.product_id = r.product_id`, orderID); err != nil {
+		return fmt.Errorf("restock cancelled order: %w", err)
+	}
+
+	return tx.Commit(ctx)
 }
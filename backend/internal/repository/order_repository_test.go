@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+func TestOrderTotals(t *testing.T) {
+	freeOver := 100.0
+	flat := &models.ShippingRate{BaseFee: 30}
+	threshold := &models.ShippingRate{BaseFee: 30, FreeOver: &freeOver}
+
+	tests := []struct {
+		name                         string
+		subtotal, discount, shipping float64
+		rate                         *models.ShippingRate
+		wantDiscount, wantShipping   float64
+		wantTotal                    float64
+	}{
+		{"no discount", 80, 0, 30, flat, 0, 30, 110},
+		{"discount", 80, 20, 30, flat, 20, 30, 90},
+		{"discount capped at subtotal", 80, 120, 30, flat, 80, 30, 30},
+		{"below threshold", 90, 0, 30, threshold, 0, 30, 120},
+		{"at threshold", 100, 0, 30, threshold, 0, 0, 100},
+		{"discount takes it below threshold", 120, 30, 30, threshold, 30, 30, 120},
+	}
+	for _, tt := range tests {
+		discount, shipping, total := orderTotals(tt.subtotal, tt.discount, tt.shipping, tt.rate)
+		if discount != tt.wantDiscount || shipping != tt.wantShipping || total != tt.wantTotal {
+			t.Errorf("%s: orderTotals = %v, %v, %v, want %v, %v, %v", tt.name,
+				discount, shipping, total, tt.wantDiscount, tt.wantShipping, tt.wantTotal)
+		}
+	}
+}
+
+func TestCreateOrderReservesAndCancelRestocks(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	products := NewProductRepository(pool)
+	carts := NewCartRepository(pool)
+	orders := NewOrderRepository(pool)
+
+	square := models.SizeTypeSquare
+	faiachun := createTestProduct(t, products, "FC-1", models.ProductTypeFaiachun, 20, 5, models.SizeTypeVRect, square)
+	bag := createTestProduct(t, products, "BAG-1", models.ProductTypeBag, 50, 3)
+	bundle := createTestProduct(t, products, "SET-1", models.ProductTypeBundle, 60, 0)
+	err := products.SetBundleComponents(ctx, bundle, []BundleComponentParams{
+		{ProductID: faiachun, SizeType: &square, Quantity: 1},
+		{ProductID: bag, Quantity: 1},
+	})
+	if err != nil {
+		t.Fatalf("SetBundleComponents: %v", err)
+	}
+
+	var clientID int64
+	if err := pool.QueryRow(ctx, `INSERT INTO client (phone) VALUES ('91234567') RETURNING client_id`).Scan(&clientID); err != nil {
+		t.Fatalf("insert client: %v", err)
+	}
+	if _, err := pool.Exec(ctx, `INSERT INTO ebuy_store (store_id, store_name) VALUES ('S1', 'Store 1')`); err != nil {
+		t.Fatalf("insert store: %v", err)
+	}
+	cart, err := carts.CreateCart(ctx, &clientID)
+	if err != nil {
+		t.Fatalf("CreateCart: %v", err)
+	}
+	for _, id := range []int64{bundle, bag} {
+		if err := carts.AddItemToCart(ctx, cart.CartID, id, nil, nil, 1); err != nil {
+			t.Fatalf("AddItemToCart(%d): %v", id, err)
+		}
+	}
+
+	order, err := orders.CreateOrder(ctx, CreateOrderParams{
+		ClientID:    clientID,
+		EbuyStoreID: "S1",
+		Name:        "Test",
+		CartID:      cart.CartID,
+	})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	// 60 + 50, and the seeded 5.00 pickup rate.
+	if order.TotalAmount != 115 || order.ShippingAmount != 5 {
+		t.Errorf("total = %v, shipping = %v, want 115, 5", order.TotalAmount, order.ShippingAmount)
+	}
+
+	// The bundle took one square and one bag; the bag line another bag.
+	checkStock := func(when string, wantSquare, wantBag int) {
+		t.Helper()
+		if got := variantStock(t, pool, faiachun)[square]; got != wantSquare {
+			t.Errorf("%s: square stock = %d, want %d", when, got, wantSquare)
+		}
+		if got := productStock(t, pool, bag); got != wantBag {
+			t.Errorf("%s: bag stock = %d, want %d", when, got, wantBag)
+		}
+	}
+	checkStock("after checkout", 1, 1)
+
+	items, err := orders.GetOrderItems(ctx, order.OrderID)
+	if err != nil {
+		t.Fatalf("GetOrderItems: %v", err)
+	}
+	var bundleLine int64
+	for _, item := range items {
+		if item.ProductID == bundle {
+			bundleLine = item.OrderItemID
+		}
+	}
+	var components int
+	for _, item := range items {
+		if item.BundleItemID != nil {
+			if *item.BundleItemID != bundleLine || item.UnitPrice != 0 {
+				t.Errorf("component line %+v, want a zero-priced line of %d", item, bundleLine)
+			}
+			components++
+		}
+	}
+	if len(items) != 4 || components != 2 {
+		t.Errorf("got %d lines with %d components, want 4 with 2", len(items), components)
+	}
+
+	if err := orders.UpdateStatus(ctx, order.OrderID, models.OrderStatusCancelled); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	checkStock("after cancelling", 2, 3)
+
+	if err := orders.UpdateStatus(ctx, order.OrderID, models.OrderStatusPending); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	checkStock("after reopening", 1, 1)
+
+	// Reopening fails, and takes nothing, once the stock has been sold.
+	if err := orders.UpdateStatus(ctx, order.OrderID, models.OrderStatusCancelled); err != nil {
+		t.Fatalf("cancel again: %v", err)
+	}
+	if _, err := pool.Exec(ctx, `UPDATE products SET quantity = 1 WHERE product_id = $1`, bag); err != nil {
+		t.Fatalf("sell bags: %v", err)
+	}
+	if err := orders.UpdateStatus(ctx, order.OrderID, models.OrderStatusPending); err != ErrInsufficientStock {
+		t.Errorf("reopen without stock: err = %v, want %v", err, ErrInsufficientStock)
+	}
+	checkStock("after failed reopening", 2, 1)
+}
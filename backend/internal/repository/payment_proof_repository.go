@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// PaymentProofRepository handles database operations for payment proofs.
+type PaymentProofRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPaymentProofRepository(db *pgxpool.Pool) *PaymentProofRepository {
+	return &PaymentProofRepository{db: db}
+}
+
+// GetByID retrieves a payment proof by ID.
+func (r *PaymentProofRepository) GetByID(ctx context.Context, proofID int64) (*models.PaymentProof, error) {
+	const query = `
+		SELECT proof_id, order_id, client_id, payment_method, amount, proof_path, status, created_at
+		FROM payment_proofs
+		WHERE proof_id = $1`
+
+	return scanPaymentProof(r.db.QueryRow(ctx, query, proofID))
+}
+
+// GetLatestByOrderID retrieves the most recent proof submitted for an order.
+func (r *PaymentProofRepository) GetLatestByOrderID(ctx context.Context, orderID int64) (*models.PaymentProof, error) {
+	const query = `
+		SELECT proof_id, order_id, client_id, payment_method, amount, proof_path, status, created_at
+		FROM payment_proofs
+		WHERE order_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	return scanPaymentProof(r.db.QueryRow(ctx, query, orderID))
+}
+
+func scanPaymentProof(row pgx.Row) (*models.PaymentProof, error) {
+	var p models.PaymentProof
+	if err := row.Scan(
+		&p.ProofID, &p.OrderID, &p.ClientID, &p.PaymentMethod, &p.Amount, &p.ProofPath, &p.Status, &p.CreatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// ErrNotBundle is returned when setting components on a product that is not
+// a bundle.
+var ErrNotBundle = errors.New("product is not a bundle")
+
+// ErrNestedBundle is returned when a bundle component is itself a bundle.
+var ErrNestedBundle = errors.New("bundle components cannot be bundles")
+
+// ErrBundleSized is returned when a bundle would be given sizes. Bundles
+// hold no variants; their stock comes from the components.
+var ErrBundleSized = errors.New("bundles cannot be sold per size")
+
+// ErrBundleUnavailable is returned when a bundle has no components or one of
+// them cannot currently be sold.
+var ErrBundleUnavailable = errors.New("bundle not available")
+
+// BundleComponentParams names one component of a bundle.
+type BundleComponentParams struct {
+	ProductID int64
+	SizeType  *models.SizeType
+	Quantity  int
+}
+
+// bundleComponent is a component together with its stock: that of its
+// variant, or products.quantity for products not sold per size.
+type bundleComponent struct {
+	models.BundleComponent
+	Stock int
+}
+
+// rowQuerier is satisfied by both the pool and a transaction.
+type rowQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// getBundleComponents loads the components of several bundles at once, in
+// position order.
+func getBundleComponents(ctx context.Context, db rowQuerier, bundleIDs []int64) (map[int64][]bundleComponent, error) {
+	rows, err := db.Query(ctx, `
+		SELECT bc.bundle_product_id, cp.product_id, cp.product_name, cp.slug, cp.product_type,
+		       bc.size_type, v.variant_id, cp.sku || COALESCE(v.sku_suffix, ''), bc.quantity,
+		       COALESCE(cp.is_active, false) AND CASE
+		           WHEN bc.size_type IS NULL THEN NOT EXISTS (SELECT 1 FROM product_variants WHERE product_id = cp.product_id)
+		           ELSE COALESCE(v.is_active, false)
+		       END,
+		       COALESCE(CASE WHEN bc.size_type IS NULL THEN cp.quantity ELSE v.quantity END, 0)
+		FROM bundle_components bc
+		JOIN products cp ON cp.product_id = bc.component_product_id
+		LEFT JOIN product_variants v ON v.product_id = cp.product_id AND v.size_type = bc.size_type
+		WHERE bc.bundle_product_id = ANY($1)
+		ORDER BY bc.bundle_product_id, bc.position, bc.bundle_component_id`, bundleIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query bundle components: %w", err)
+	}
+	defer rows.Close()
+
+	components := make(map[int64][]bundleComponent)
+	for rows.Next() {
+		var bundleID int64
+		var bc bundleComponent
+		if err := rows.Scan(&bundleID, &bc.ProductID, &bc.ProductName, &bc.Slug, &bc.ProductType,
+			&bc.SizeType, &bc.VariantID, &bc.SKU, &bc.Quantity, &bc.Available, &bc.Stock); err != nil {
+			return nil, fmt.Errorf("scan bundle component: %w", err)
+		}
+		components[bundleID] = append(components[bundleID], bc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return components, nil
+}
+
+// bundleStockSQL is the number of bundles {p} (a products alias) that can be
+// made now, following checkBundleStock: 0 without components or while one
+// is unavailable, else the fewest sets any component's stock allows.
+func bundleStockSQL(alias string) string {
+	return strings.NewReplacer("{p}", alias).Replace(`(
+		SELECT CASE
+			WHEN COUNT(*) = 0 OR NOT bool_and(bs.available) THEN 0
+			ELSE GREATEST(COALESCE(MIN(bs.stock / bs.needed), 0), 0)
+		END::int
+		FROM (
+			SELECT COALESCE(cp.is_active, false) AND CASE
+			           WHEN bc.size_type IS NULL THEN NOT EXISTS (SELECT 1 FROM product_variants WHERE product_id = cp.product_id)
+			           ELSE COALESCE(cv.is_active, false)
+			       END AS available,
+			       COALESCE(CASE WHEN bc.size_type IS NULL THEN cp.quantity ELSE cv.quantity END, 0) AS stock,
+			       SUM(bc.quantity) AS needed
+			FROM bundle_components bc
+			JOIN products cp ON cp.product_id = bc.component_product_id
+			LEFT JOIN product_variants cv ON cv.product_id = cp.product_id AND cv.size_type = bc.size_type
+			WHERE bc.bundle_product_id = {p}.product_id
+			GROUP BY cp.product_id, cp.is_active, cp.quantity, bc.size_type, cv.variant_id, cv.is_active, cv.quantity
+		) bs)`)
+}
+
+// stockKey names the row a line takes stock from: its variant, or the
+// product itself for products not sold per size.
+type stockKey struct {
+	productID int64
+	variantID int64
+}
+
+func stockKeyOf(productID int64, variantID *int64) stockKey {
+	if variantID != nil {
+		return stockKey{variantID: *variantID}
+	}
+	return stockKey{productID: productID}
+}
+
+// checkBundleStock verifies that quantity bundles can be made from the
+// components' current stock.
+func checkBundleStock(ctx context.Context, db rowQuerier, bundleID int64, quantity int) error {
+	components, err := getBundleComponents(ctx, db, []int64{bundleID})
+	if err != nil {
+		return err
+	}
+	if len(components[bundleID]) == 0 {
+		return ErrBundleUnavailable
+	}
+	needed := make(map[stockKey]int)
+	for _, bc := range components[bundleID] {
+		if !bc.Available {
+			return ErrBundleUnavailable
+		}
+		// The same product or size may appear on several component rows.
+		key := stockKeyOf(bc.ProductID, bc.VariantID)
+		needed[key] += bc.Quantity * quantity
+		if needed[key] > bc.Stock {
+			return ErrInsufficientStock
+		}
+	}
+	return nil
+}
+
+// checkBundleUpdate rejects an update that would leave productID a bundle
+// with sizes, or a bundle used as another bundle's component. It locks the
+// product; SetBundleComponents share-locks components, so neither check can
+// race the other.
+func checkBundleUpdate(ctx context.Context, tx pgx.Tx, productID int64, params ProductParams) error {
+	var productType models.ProductType
+	var hasSizes, hasVariants, isComponent bool
+	err := tx.QueryRow(ctx, `
+		SELECT p.product_type, COALESCE(cardinality(p.available_sizes), 0) > 0,
+		       EXISTS (SELECT 1 FROM product_variants WHERE product_id = p.product_id),
+		       EXISTS (SELECT 1 FROM bundle_components WHERE component_product_id = p.product_id)
+		FROM products p
+		WHERE p.product_id = $1
+		FOR UPDATE OF p`, productID).Scan(&productType, &hasSizes, &hasVariants, &isComponent)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrNotFound
+		}
+		return fmt.Errorf("get product: %w", err)
+	}
+	if params.Type != nil {
+		productType = *params.Type
+	}
+	if productType != models.ProductTypeBundle {
+		return nil
+	}
+	if isComponent {
+		return ErrNestedBundle
+	}
+	if params.AvailableSizes != nil {
+		hasSizes = len(params.AvailableSizes) > 0
+	}
+	if hasSizes || hasVariants {
+		return ErrBundleSized
+	}
+	return nil
+}
+
+// GetBundleComponents retrieves a bundle's components in position order.
+func (r *ProductRepository) GetBundleComponents(ctx context.Context, productID int64) ([]models.BundleComponent, error) {
+	components, err := getBundleComponents(ctx, r.db, []int64{productID})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]models.BundleComponent, 0, len(components[productID]))
+	for _, bc := range components[productID] {
+		result = append(result, bc.BundleComponent)
+	}
+	return result, nil
+}
+
+// ListBundleComponents returns the components of every bundle, keyed by
+// bundle ID. It backs the catalogue export and import.
+func (r *ProductRepository) ListBundleComponents(ctx context.Context) (map[int64][]models.BundleComponent, error) {
+	rows, err := r.db.Query(ctx, `SELECT DISTINCT bundle_product_id FROM bundle_components`)
+	if err != nil {
+		return nil, fmt.Errorf("query bundles: %w", err)
+	}
+	var bundleIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan bundle: %w", err)
+		}
+		bundleIDs = append(bundleIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	components, err := getBundleComponents(ctx, r.db, bundleIDs)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int64][]models.BundleComponent, len(components))
+	for bundleID, list := range components {
+		for _, bc := range list {
+			out[bundleID] = append(out[bundleID], bc.BundleComponent)
+		}
+	}
+	return out, nil
+}
+
+// SetBundleComponents replaces a bundle's components, keeping the given
+// order. Components must exist and not be bundles; a size must be given for,
+// and only for, products sold per size.
+func (r *ProductRepository) SetBundleComponents(ctx context.Context, productID int64, components []BundleComponentParams) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var productType models.ProductType
+	err = tx.QueryRow(ctx, `SELECT product_type FROM products WHERE product_id = $1 FOR UPDATE`, productID).Scan(&productType)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrNotFound
+		}
+		return fmt.Errorf("get product: %w", err)
+	}
+	if productType != models.ProductTypeBundle {
+		return ErrNotBundle
+	}
+
+	for _, c := range components {
+		var componentType models.ProductType
+		var hasVariants, sizeOffered bool
+		err := tx.QueryRow(ctx, `
+			SELECT p.product_type,
+			       EXISTS (SELECT 1 FROM product_variants WHERE product_id = p.product_id),
+			       EXISTS (SELECT 1 FROM product_variants WHERE product_id = p.product_id AND size_type = $2::size_type_enum)
+			FROM products p
+			WHERE p.product_id = $1
+			FOR SHARE OF p`, c.ProductID, c.SizeType).Scan(&componentType, &hasVariants, &sizeOffered)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return ErrNotFound
+			}
+			return fmt.Errorf("get component: %w", err)
+		}
+		if componentType == models.ProductTypeBundle {
+			return ErrNestedBundle
+		}
+		if hasVariants != (c.SizeType != nil) || (hasVariants && !sizeOffered) {
+			return ErrVariantUnavailable
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM bundle_components WHERE bundle_product_id = $1`, productID); err != nil {
+		return fmt.Errorf("clear bundle components: %w", err)
+	}
+	for i, c := range components {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO bundle_components (bundle_product_id, component_product_id, size_type, quantity, position)
+			VALUES ($1, $2, $3, $4, $5)`, productID, c.ProductID, c.SizeType, c.Quantity, i); err != nil {
+			return fmt.Errorf("insert bundle component: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
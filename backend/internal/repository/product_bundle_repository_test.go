@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+func TestStockKeyOf(t *testing.T) {
+	variantID := int64(7)
+	if got, want := stockKeyOf(3, &variantID), (stockKey{variantID: 7}); got != want {
+		t.Errorf("stockKeyOf(3, 7) = %v, want %v", got, want)
+	}
+	if got, want := stockKeyOf(3, nil), (stockKey{productID: 3}); got != want {
+		t.Errorf("stockKeyOf(3, nil) = %v, want %v", got, want)
+	}
+	// A product and a variant sharing an ID must not share stock.
+	if stockKeyOf(7, nil) == stockKeyOf(3, &variantID) {
+		t.Error("product 7 and variant 7 share a stock key")
+	}
+}
+
+func TestCheckBundleStock(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewProductRepository(pool)
+
+	square := models.SizeTypeSquare
+	faiachun := createTestProduct(t, repo, "FC-1", models.ProductTypeFaiachun, 20, 5, models.SizeTypeVRect, models.SizeTypeSquare)
+	bag := createTestProduct(t, repo, "BAG-1", models.ProductTypeBag, 50, 3)
+	bundle := createTestProduct(t, repo, "SET-1", models.ProductTypeBundle, 60, 0)
+
+	if err := checkBundleStock(ctx, pool, bundle, 1); err != ErrBundleUnavailable {
+		t.Errorf("without components: err = %v, want %v", err, ErrBundleUnavailable)
+	}
+
+	// The square size holds 2 of the 5 units; the bag, having no sizes, its
+	// own 3. The faiachun appears twice, so its stock is needed by both rows.
+	err := repo.SetBundleComponents(ctx, bundle, []BundleComponentParams{
+		{ProductID: faiachun, SizeType: &square, Quantity: 1},
+		{ProductID: bag, Quantity: 1},
+		{ProductID: faiachun, SizeType: &square, Quantity: 1},
+	})
+	if err != nil {
+		t.Fatalf("SetBundleComponents: %v", err)
+	}
+
+	if err := checkBundleStock(ctx, pool, bundle, 1); err != nil {
+		t.Errorf("1 bundle: %v", err)
+	}
+	if err := checkBundleStock(ctx, pool, bundle, 2); err != ErrInsufficientStock {
+		t.Errorf("2 bundles: err = %v, want %v", err, ErrInsufficientStock)
+	}
+
+	var stock int
+	if err := pool.QueryRow(ctx, `SELECT `+bundleStockSQL("p")+` FROM products p WHERE p.product_id = $1`, bundle).Scan(&stock); err != nil {
+		t.Fatalf("bundle stock: %v", err)
+	}
+	if stock != 1 {
+		t.Errorf("bundle stock = %d, want 1", stock)
+	}
+
+	inactive := false
+	if err := repo.UpdateProduct(ctx, bag, ProductParams{IsActive: &inactive}); err != nil {
+		t.Fatalf("UpdateProduct: %v", err)
+	}
+	if err := checkBundleStock(ctx, pool, bundle, 1); err != ErrBundleUnavailable {
+		t.Errorf("inactive component: err = %v, want %v", err, ErrBundleUnavailable)
+	}
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// getCustomisationOptions loads the customisation options of several
+// products at once, in position order.
+func getCustomisationOptions(ctx context.Context, db rowQuerier, productIDs []int64) (map[int64][]models.CustomisationOption, error) {
+	rows, err := db.Query(ctx, `
+		SELECT option_id, product_id, option_key, label, option_type, max_length, choices,
+		       is_required, surcharge::float8
+		FROM product_customisation_options
+		WHERE product_id = ANY($1)
+		ORDER BY product_id, position, option_id`, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query customisation options: %w", err)
+	}
+	defer rows.Close()
+
+	options := make(map[int64][]models.CustomisationOption)
+	for rows.Next() {
+		var o models.CustomisationOption
+		if err := rows.Scan(&o.ID, &o.ProductID, &o.Key, &o.Label, &o.Type, &o.MaxLength, &o.Choices,
+			&o.IsRequired, &o.Surcharge); err != nil {
+			return nil, fmt.Errorf("scan customisation option: %w", err)
+		}
+		options[o.ProductID] = append(options[o.ProductID], o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return options, nil
+}
+
+// GetCustomisationOptions retrieves a product's customisation options in
+// position order.
+func (r *ProductRepository) GetCustomisationOptions(ctx context.Context, productID int64) ([]models.CustomisationOption, error) {
+	options, err := getCustomisationOptions(ctx, r.db, []int64{productID})
+	if err != nil {
+		return nil, err
+	}
+	if options[productID] == nil {
+		return []models.CustomisationOption{}, nil
+	}
+	return options[productID], nil
+}
+
+// SetCustomisationOptions replaces a product's customisation options,
+// keeping the given order. Values already in carts are checked again at
+// checkout.
+func (r *ProductRepository) SetCustomisationOptions(ctx context.Context, productID int64, options []models.CustomisationOption) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	err = tx.QueryRow(ctx, `SELECT true FROM products WHERE product_id = $1 FOR UPDATE`, productID).Scan(&exists)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrNotFound
+		}
+		return fmt.Errorf("get product: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM product_customisation_options WHERE product_id = $1`, productID); err != nil {
+		return fmt.Errorf("clear customisation options: %w", err)
+	}
+	for i, o := range options {
+		choices := o.Choices
+		if choices == nil {
+			choices = []string{}
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO product_customisation_options (
+				product_id, option_key, label, option_type, max_length, choices, is_required, surcharge, position
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			productID, o.Key, o.Label, o.Type, o.MaxLength, choices, o.IsRequired, o.Surcharge, i); err != nil {
+			return fmt.Errorf("insert customisation option: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
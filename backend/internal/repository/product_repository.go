@@ -2,9 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/ryangel/ryangel-backend/internal/models"
@@ -12,13 +16,29 @@ import (
 
 // ProductRepository handles database operations for products.
 type ProductRepository struct {
-	db *pgxpool.Pool
+	db productDB
+}
+
+// productDB is the pool, or a transaction whose Begin opens a savepoint.
+type productDB interface {
+	rowQuerier
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
 func NewProductRepository(db *pgxpool.Pool) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
+// InTx runs fn with a repository whose reads and writes all go through one
+// transaction, committed when fn returns nil. Methods that open their own
+// transaction run as savepoints inside it.
+func (r *ProductRepository) InTx(ctx context.Context, fn func(*ProductRepository) error) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return fn(&ProductRepository{db: tx})
+	})
+}
+
 // ProductFilters represents filters for product listing.
 type ProductFilters struct {
 	Query        string
@@ -29,6 +49,8 @@ type ProductFilters struct {
 	PriceMax     *float64
 	SKU          string
 	IsActive     *bool
+	// CollectionID limits the listing to one collection's products.
+	CollectionID *int64
 }
 
 // ProductSort represents sorting options.
@@ -37,23 +59,115 @@ type ProductSort struct {
 	Order string // "asc" or "desc"
 }
 
-// ListProducts retrieves products with pagination and filters.
-func (r *ProductRepository) ListProducts(ctx context.Context, filters ProductFilters, sort ProductSort, page, pageSize int) ([]models.ProductWithDetails, int, error) {
-	offset := (page - 1) * pageSize
+// productSortExprs whitelists the sortable fields. Price sorts by the lowest
+// active size price, falling back to the product price; popularity counts units
+// sold on orders that were not cancelled or refunded.
+var productSortExprs = map[string]string{
+	"price": `COALESCE((SELECT MIN(v.price) FROM product_variants v
+		WHERE v.product_id = p.product_id AND COALESCE(v.is_active, true)), p.price)`,
+	"name":       "p.product_name",
+	"created_at": "p.created_at",
+	"popularity": `COALESCE((SELECT SUM(oi.quantity) FROM order_items oi
+		JOIN orders o ON o.order_id = oi.order_id
+		WHERE oi.product_id = p.product_id AND o.order_status NOT IN ('cancelled', 'refunded')), 0)`,
+	"featured": "p.is_featured",
+	// relevance and collection are rendered by orderBy because they need the
+	// filter arguments.
+	"relevance":  "",
+	"collection": "",
+}
+
+// Valid reports whether the sort field and order are supported.
+func (s ProductSort) Valid() bool {
+	_, ok := productSortExprs[s.Field]
+	return ok && (s.Order == "asc" || s.Order == "desc")
+}
+
+// orderBy renders the ORDER BY list. Ties are broken by newest first and then
+// product_id so that pages never overlap. "featured" always lists featured
+// products first. queryArg is the placeholder index of the search query
+// (followed by its LIKE pattern), or 0 when not searching; collectionArg is
+// that of the collection filter, or 0.
+func (s ProductSort) orderBy(queryArg, collectionArg int) string {
+	if !s.Valid() || (s.Field == "relevance" && queryArg == 0) || (s.Field == "collection" && collectionArg == 0) {
+		s = ProductSort{Field: "created_at", Order: "desc"}
+	}
+	dir := strings.ToUpper(s.Order)
+	switch s.Field {
+	case "relevance":
+		// Word rank, plus a boost when the name contains the phrase and a
+		// trigram similarity term that also ranks CJK matches.
+		return fmt.Sprintf(`ts_rank_cd(p.search_vector, websearch_to_tsquery('simple', $%[1]d))
+			+ CASE WHEN p.product_name ILIKE $%[2]d THEN 1 ELSE 0 END
+			+ similarity(p.product_name, $%[1]d) %[3]s, p.created_at DESC, p.product_id DESC`,
+			queryArg, queryArg+1, dir)
+	case "collection":
+		return fmt.Sprintf(`(SELECT cp.position FROM collection_products cp
+			WHERE cp.collection_id = $%d AND cp.product_id = p.product_id) %s, p.created_at DESC, p.product_id DESC`,
+			collectionArg, dir)
+	case "featured":
+		return "p.is_featured DESC, p.created_at DESC, p.product_id DESC"
+	case "created_at":
+		return fmt.Sprintf("p.created_at %s, p.product_id %s", dir, dir)
+	default:
+		return fmt.Sprintf("%s %s, p.created_at DESC, p.product_id DESC", productSortExprs[s.Field], dir)
+	}
+}
+
+// Facet dimensions whose own filter is left out when counting that facet, so
+// the counts show what selecting another value would return.
+const (
+	facetProductType = "product_type"
+	facetCategory    = "category"
+	facetPrice       = "price"
+)
 
-	// Build WHERE clause
-	whereParts := []string{"p.is_active = true"} // Default to active products
+// where renders the WHERE clause and its arguments for the filters, skipping
+// the filter of the omitted facet dimension. queryArg and collectionArg are
+// the placeholder indexes of the search query and collection, or 0 when not
+// filtered by them.
+func (filters ProductFilters) where(omit string) (string, []interface{}, int, int) {
+	// Default to listed products: active and inside their publishing window.
+	whereParts := []string{"p.is_active = true", publishedCond("p")}
 	args := []interface{}{}
 	argCount := 1
 
+	collectionArg := 0
+	if filters.CollectionID != nil {
+		collectionArg = argCount
+		whereParts = append(whereParts, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM collection_products cp
+			WHERE cp.collection_id = $%d AND cp.product_id = p.product_id)`, argCount))
+		args = append(args, *filters.CollectionID)
+		argCount++
+	}
+
+	// Full-text match on words, with a trigram-indexed substring match as the
+	// fallback for Chinese phrases and partial SKUs.
+	queryArg := 0
 	if filters.Query != "" {
-		whereParts = append(whereParts, fmt.Sprintf("(p.product_name ILIKE $%d OR p.product_description ILIKE $%d)", argCount, argCount+1))
-		likeQuery := "%" + filters.Query + "%"
-		args = append(args, likeQuery, likeQuery)
+		queryArg = argCount
+		args = append(args, filters.Query, "%"+escapeLike(filters.Query)+"%")
 		argCount += 2
+
+		matchConds := []string{
+			fmt.Sprintf("p.search_vector @@ websearch_to_tsquery('simple', $%d)", queryArg),
+			fmt.Sprintf("p.search_text ILIKE $%d", queryArg+1),
+		}
+		// Multi-word queries also match when every term appears somewhere.
+		if terms := searchFallbackTerms(filters.Query); len(terms) > 1 {
+			termConds := make([]string, len(terms))
+			for i, term := range terms {
+				termConds[i] = fmt.Sprintf("p.search_text ILIKE $%d", argCount)
+				args = append(args, "%"+escapeLike(term)+"%")
+				argCount++
+			}
+			matchConds = append(matchConds, "("+strings.Join(termConds, " AND ")+")")
+		}
+		whereParts = append(whereParts, "("+strings.Join(matchConds, " OR ")+")")
 	}
 
-	if filters.ProductType != nil {
+	if filters.ProductType != nil && omit != facetProductType {
 		whereParts = append(whereParts, fmt.Sprintf("p.product_type = $%d", argCount))
 		args = append(args, *filters.ProductType)
 		argCount++
@@ -65,7 +179,63 @@ func (r *ProductRepository) ListProducts(ctx context.Context, filters ProductFil
 		argCount++
 	}
 
-	whereClause := strings.Join(whereParts, " AND ")
+	// Category filter includes products of every descendant category. A
+	// category outside its publishing window hides its subtree.
+	if filters.CategoryID != nil && omit != facetCategory {
+		whereParts = append(whereParts, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM product_categories pc
+			WHERE pc.product_id = p.product_id AND pc.category_id IN (
+				WITH RECURSIVE tree AS (
+					SELECT category_id FROM categories c WHERE category_id = $%d AND %s
+					UNION
+					SELECT c.category_id FROM categories c JOIN tree t ON c.parent_category_id = t.category_id
+					WHERE %s
+				)
+				SELECT category_id FROM tree
+			))`, argCount, publishedCond("c"), publishedCond("c")))
+		args = append(args, *filters.CategoryID)
+		argCount++
+	}
+
+	// Price bounds match the product price or the price of any active size.
+	if (filters.PriceMin != nil || filters.PriceMax != nil) && omit != facetPrice {
+		var productConds, variantConds []string
+		if filters.PriceMin != nil {
+			productConds = append(productConds, fmt.Sprintf("p.price >= $%d", argCount))
+			variantConds = append(variantConds, fmt.Sprintf("v.price >= $%d", argCount))
+			args = append(args, *filters.PriceMin)
+			argCount++
+		}
+		if filters.PriceMax != nil {
+			productConds = append(productConds, fmt.Sprintf("p.price <= $%d", argCount))
+			variantConds = append(variantConds, fmt.Sprintf("v.price <= $%d", argCount))
+			args = append(args, *filters.PriceMax)
+			argCount++
+		}
+		whereParts = append(whereParts, fmt.Sprintf(`((%s) OR EXISTS (
+			SELECT 1 FROM product_variants v
+			WHERE v.product_id = p.product_id AND COALESCE(v.is_active, true) AND %s))`,
+			strings.Join(productConds, " AND "), strings.Join(variantConds, " AND ")))
+	}
+
+	// SKU is a case-insensitive prefix match on the product or variant SKU.
+	if filters.SKU != "" {
+		whereParts = append(whereParts, fmt.Sprintf(`(p.sku ILIKE $%d OR EXISTS (
+			SELECT 1 FROM product_variants v
+			WHERE v.product_id = p.product_id AND p.sku || v.sku_suffix ILIKE $%d))`, argCount, argCount))
+		args = append(args, escapeLike(filters.SKU)+"%")
+		argCount++
+	}
+
+	return strings.Join(whereParts, " AND "), args, queryArg, collectionArg
+}
+
+// ListProducts retrieves products with pagination and filters.
+func (r *ProductRepository) ListProducts(ctx context.Context, filters ProductFilters, sort ProductSort, page, pageSize int) ([]models.ProductWithDetails, int, error) {
+	offset := (page - 1) * pageSize
+
+	whereClause, args, queryArg, collectionArg := filters.where("")
+	argCount := len(args) + 1
 
 	// Count query
 	countQuery := fmt.Sprintf(`
@@ -84,9 +254,9 @@ func (r *ProductRepository) ListProducts(ctx context.Context, filters ProductFil
 		SELECT p.product_id
 		FROM products p
 		WHERE %s
-		ORDER BY p.created_at DESC
+		ORDER BY %s
 		LIMIT $%d OFFSET $%d`,
-		whereClause, argCount, argCount+1)
+		whereClause, sort.orderBy(queryArg, collectionArg), argCount, argCount+1)
 
 	args = append(args, pageSize, offset)
 
@@ -113,9 +283,9 @@ func (r *ProductRepository) ListProducts(ctx context.Context, filters ProductFil
 	// Use ANY($1) for the ID list
 	query := `
 		SELECT
-			p.product_id, p.product_name, p.product_description, p.product_type, p.hashtag,
+			p.product_id, p.product_name, COALESCE(p.slug, ''), p.product_description, p.product_type, p.hashtag,
 			p.sku, p.price, p.compare_at_price, p.quantity, p.is_featured, p.is_active,
-			p.available_sizes, p.created_at, p.updated_at,
+			p.available_sizes, p.created_at, p.updated_at, p.publish_at, p.unpublish_at,
 			COALESCE(pi.image_id, 0) as image_id,
 			COALESCE(pi.image_path, '') as image_path,
 			COALESCE(pi.thumbnail_path, '') as thumbnail_path,
@@ -126,7 +296,7 @@ func (r *ProductRepository) ListProducts(ctx context.Context, filters ProductFil
 		FROM products p
 		LEFT JOIN product_images pi ON p.product_id = pi.product_id
 		WHERE p.product_id = ANY($1)
-		ORDER BY p.created_at DESC, pi.sort_order ASC`
+		ORDER BY p.product_id, pi.sort_order ASC`
 
 	rows, err := r.db.Query(ctx, query, productIDs)
 	if err != nil {
@@ -136,8 +306,6 @@ func (r *ProductRepository) ListProducts(ctx context.Context, filters ProductFil
 
 	// Group products and their images
 	productMap := make(map[int64]*models.ProductWithDetails)
-	// Use a slice to maintain order from query
-	var orderedProducts []*models.ProductWithDetails
 	
 	for rows.Next() {
 		var p models.ProductWithDetails
@@ -151,9 +319,9 @@ func (r *ProductRepository) ListProducts(ctx context.Context, filters ProductFil
 		var availableSizes []string
 
 		err := rows.Scan(
-			&p.ID, &p.Name, &p.Description, &p.Type, &p.Hashtag,
+			&p.ID, &p.Name, &p.Slug, &p.Description, &p.Type, &p.Hashtag,
 			&p.SKU, &p.Price, &p.CompareAtPrice, &p.Quantity, &p.IsFeatured, &p.IsActive,
-			&availableSizes, &p.CreatedAt, &p.UpdatedAt,
+			&availableSizes, &p.CreatedAt, &p.UpdatedAt, &p.PublishAt, &p.UnpublishAt,
 			&imageID, &imagePath, &thumbnailPath, &altText, &sizeType, &sortOrder, &isPrimary,
 		)
 		if err != nil {
@@ -188,7 +356,6 @@ func (r *ProductRepository) ListProducts(ctx context.Context, filters ProductFil
 			}
 		} else {
 			// New product
-			p.Categories = []models.Category{} // Empty for now
 			if imageID != 0 {
 				altTextStr := ""
 				if altText != nil {
@@ -209,7 +376,6 @@ func (r *ProductRepository) ListProducts(ctx context.Context, filters ProductFil
 				p.Images = []models.ProductImage{}
 			}
 			productMap[p.ID] = &p
-			orderedProducts = append(orderedProducts, &p)
 		}
 	}
 
@@ -217,22 +383,209 @@ func (r *ProductRepository) ListProducts(ctx context.Context, filters ProductFil
 		return nil, 0, fmt.Errorf("rows error: %w", err)
 	}
 
-	// Convert ordered pointers to value slice
-	products := make([]models.ProductWithDetails, len(orderedProducts))
-	for i, p := range orderedProducts {
-		products[i] = *p
+	variants, err := r.getVariantsByProductIDs(ctx, productIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	categories, err := r.getCategoriesByProductIDs(ctx, productIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	ratings, err := r.getRatingsByProductIDs(ctx, productIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	prices, err := getRecentPrices(ctx, r.db, productIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Return products in the sorted order of the ID page
+	products := make([]models.ProductWithDetails, 0, len(productIDs))
+	for _, id := range productIDs {
+		p, ok := productMap[id]
+		if !ok {
+			continue
+		}
+		p.Variants = variants[p.ID]
+		if p.Variants == nil {
+			p.Variants = []models.ProductVariant{}
+		}
+		p.Categories = categories[p.ID]
+		if p.Categories == nil {
+			p.Categories = []models.Category{}
+		}
+		p.RatingAverage, p.ReviewCount = ratings[p.ID].Average, ratings[p.ID].Count
+		applyRecentPrices(p, prices)
+		p.BuildGalleries()
+		products = append(products, *p)
 	}
 
 	return products, total, nil
 }
 
+// priceBucketEdges are the boundaries of the price facet buckets.
+var priceBucketEdges = []float64{50, 100, 200, 500}
+
+// maxHashtagFacets caps the hashtag facet to the most common tags.
+const maxHashtagFacets = 20
+
+// ListProductFacets counts the products matching filters per product type,
+// category (including descendants), size, hashtag and price bucket. Product
+// type, category and price counts leave out their own filter.
+func (r *ProductRepository) ListProductFacets(ctx context.Context, filters ProductFilters) (*models.ProductFacets, error) {
+	facets := &models.ProductFacets{}
+	var err error
+
+	where, args, _, _ := filters.where(facetProductType)
+	facets.ProductTypes, err = r.facetCounts(ctx, fmt.Sprintf(`
+		SELECT p.product_type::text, COUNT(*)
+		FROM products p
+		WHERE %s
+		GROUP BY p.product_type
+		ORDER BY p.product_type`, where), args)
+	if err != nil {
+		return nil, fmt.Errorf("product type facets: %w", err)
+	}
+
+	where, args, _, _ = filters.where("")
+	facets.Sizes, err = r.facetCounts(ctx, fmt.Sprintf(`
+		SELECT s.size::text, COUNT(DISTINCT p.product_id)
+		FROM products p
+		CROSS JOIN LATERAL unnest(p.available_sizes) AS s(size)
+		WHERE %s
+		GROUP BY s.size
+		ORDER BY s.size`, where), args)
+	if err != nil {
+		return nil, fmt.Errorf("size facets: %w", err)
+	}
+
+	facets.Hashtags, err = r.facetCounts(ctx, fmt.Sprintf(`
+		SELECT ltrim(p.hashtag, '#') AS tag, COUNT(*)
+		FROM products p
+		WHERE %s AND ltrim(p.hashtag, '#') <> ''
+		GROUP BY tag
+		ORDER BY COUNT(*) DESC, tag
+		LIMIT %d`, where, maxHashtagFacets), args)
+	if err != nil {
+		return nil, fmt.Errorf("hashtag facets: %w", err)
+	}
+
+	if facets.Categories, err = r.categoryFacets(ctx, filters); err != nil {
+		return nil, err
+	}
+	if facets.PriceBuckets, err = r.priceFacets(ctx, filters); err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+// facetCounts runs a query returning (value, count) rows.
+func (r *ProductRepository) facetCounts(ctx context.Context, query string, args []interface{}) ([]models.FacetCount, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []models.FacetCount{}
+	for rows.Next() {
+		var fc models.FacetCount
+		if err := rows.Scan(&fc.Value, &fc.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, fc)
+	}
+	return counts, rows.Err()
+}
+
+// categoryFacets counts matching products per active category, including
+// products of descendant categories. Categories without matches are omitted.
+func (r *ProductRepository) categoryFacets(ctx context.Context, filters ProductFilters) ([]models.CategoryFacet, error) {
+	where, args, _, _ := filters.where(facetCategory)
+	query := fmt.Sprintf(`
+		WITH RECURSIVE subtree AS (
+			SELECT category_id AS root_id, category_id FROM categories
+			UNION ALL
+			SELECT s.root_id, c.category_id FROM categories c
+			JOIN subtree s ON c.parent_category_id = s.category_id
+		), matched AS (
+			SELECT p.product_id FROM products p WHERE %s
+		)
+		SELECT c.category_id, c.category_name, c.parent_category_id, COUNT(DISTINCT m.product_id)
+		FROM categories c
+		JOIN subtree s ON s.root_id = c.category_id
+		JOIN product_categories pc ON pc.category_id = s.category_id
+		JOIN matched m ON m.product_id = pc.product_id
+		WHERE COALESCE(c.is_active, true) AND `+publishedCond("c")+`
+		GROUP BY c.category_id, c.category_name, c.parent_category_id
+		ORDER BY c.category_name, c.category_id`, where)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("category facets: %w", err)
+	}
+	defer rows.Close()
+
+	facets := []models.CategoryFacet{}
+	for rows.Next() {
+		var f models.CategoryFacet
+		if err := rows.Scan(&f.ID, &f.Name, &f.ParentID, &f.Count); err != nil {
+			return nil, fmt.Errorf("scan category facet: %w", err)
+		}
+		facets = append(facets, f)
+	}
+	return facets, rows.Err()
+}
+
+// priceFacets counts matching products per price bucket, using the same
+// display price as the price sort. Every bucket is returned, empty or not.
+func (r *ProductRepository) priceFacets(ctx context.Context, filters ProductFilters) ([]models.PriceBucket, error) {
+	where, args, _, _ := filters.where(facetPrice)
+	query := fmt.Sprintf(`
+		SELECT width_bucket(%s, $%d::numeric[]) AS bucket, COUNT(*)
+		FROM products p
+		WHERE %s
+		GROUP BY bucket`, productSortExprs["price"], len(args)+1, where)
+	args = append(args, priceBucketEdges)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("price facets: %w", err)
+	}
+	defer rows.Close()
+
+	buckets := make([]models.PriceBucket, len(priceBucketEdges)+1)
+	for i := range buckets {
+		if i > 0 {
+			buckets[i].Min = priceBucketEdges[i-1]
+		}
+		if i < len(priceBucketEdges) {
+			max := priceBucketEdges[i]
+			buckets[i].Max = &max
+		}
+	}
+	for rows.Next() {
+		var bucket, count int
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, fmt.Errorf("scan price facet: %w", err)
+		}
+		if bucket >= 0 && bucket < len(buckets) {
+			buckets[bucket].Count = count
+		}
+	}
+	return buckets, rows.Err()
+}
+
 // GetProductByID retrieves a single product with details by ID.
 func (r *ProductRepository) GetProductByID(ctx context.Context, productID int64) (*models.ProductWithDetails, error) {
 	query := `
 		SELECT
-			p.product_id, p.product_name, p.product_description, p.product_type, p.hashtag,
+			p.product_id, p.product_name, COALESCE(p.slug, ''), p.product_description, p.product_type, p.hashtag,
 			p.sku, p.price, p.compare_at_price, p.quantity, p.is_featured, p.is_active,
-			p.available_sizes, p.created_at, p.updated_at,
+			p.available_sizes, p.created_at, p.updated_at, p.publish_at, p.unpublish_at,
+			p.cost_price, p.weight, p.seo_title, p.seo_description, p.tags::text, p.created_by,
 			COALESCE(pi.image_id, 0) as image_id,
 			COALESCE(pi.image_path, '') as image_path,
 			COALESCE(pi.thumbnail_path, '') as thumbnail_path,
@@ -267,9 +620,10 @@ func (r *ProductRepository) GetProductByID(ctx context.Context, productID int64)
 			var availableSizes string
 
 			err := rows.Scan(
-				&p.ID, &p.Name, &p.Description, &p.Type, &p.Hashtag,
+				&p.ID, &p.Name, &p.Slug, &p.Description, &p.Type, &p.Hashtag,
 				&p.SKU, &p.Price, &p.CompareAtPrice, &p.Quantity, &p.IsFeatured, &p.IsActive,
-				&availableSizes, &p.CreatedAt, &p.UpdatedAt,
+				&availableSizes, &p.CreatedAt, &p.UpdatedAt, &p.PublishAt, &p.UnpublishAt,
+				&p.CostPrice, &p.Weight, &p.SEOTitle, &p.SEODescription, &p.Tags, &p.CreatedBy,
 				&imageID, &imagePath, &thumbnailPath, &altText, &sizeType, &sortOrder, &isPrimary,
 			)
 			if err != nil {
@@ -293,7 +647,6 @@ func (r *ProductRepository) GetProductByID(ctx context.Context, productID int64)
 				p.AvailableSizes = []models.SizeType{}
 			}
 
-			p.Categories = []models.Category{} // Empty for now
 
 			if imageID != 0 {
 				altTextStr := ""
@@ -325,6 +678,7 @@ func (r *ProductRepository) GetProductByID(ctx context.Context, productID int64)
 			// Skip the product fields and scan only image fields
 			err := rows.Scan(
 				nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+				nil, nil, nil, nil, nil, nil,
 				&imageID, &imagePath, &thumbnailPath, &altText, &sizeType, &sortOrder, &isPrimary,
 			)
 			if err != nil {
@@ -359,54 +713,1248 @@ func (r *ProductRepository) GetProductByID(ctx context.Context, productID int64)
 		return nil, ErrNotFound
 	}
 
+	if images == nil {
+		images = []models.ProductImage{}
+	}
 	p.Images = images
+	p.BuildGalleries()
+	p.ApplySEODefaults()
+
+	p.Variants, err = r.GetProductVariants(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Type == models.ProductTypeBundle {
+		p.Components, err = r.GetBundleComponents(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.CustomisationOptions, err = r.GetCustomisationOptions(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := r.getCategoriesByProductIDs(ctx, []int64{productID})
+	if err != nil {
+		return nil, err
+	}
+	p.Categories = categories[productID]
+	if p.Categories == nil {
+		p.Categories = []models.Category{}
+	}
+
+	ratings, err := r.getRatingsByProductIDs(ctx, []int64{productID})
+	if err != nil {
+		return nil, err
+	}
+	p.RatingAverage, p.ReviewCount = ratings[productID].Average, ratings[productID].Count
+
+	prices, err := getRecentPrices(ctx, r.db, []int64{productID})
+	if err != nil {
+		return nil, err
+	}
+	applyRecentPrices(p, prices)
 	return p, nil
 }
 
-func (r *ProductRepository) GetProductImages(ctx context.Context, productID int64) ([]models.ProductImage, error) {
+// ListAllProducts returns every product, active or not, ordered by SKU. It
+// backs the catalogue export and import.
+func (r *ProductRepository) ListAllProducts(ctx context.Context) ([]models.Product, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT product_id, product_name, COALESCE(slug, ''), product_description, product_type, hashtag,
+			sku, price, compare_at_price, COALESCE(quantity, 0),
+			COALESCE(available_sizes, '{}')::text[], COALESCE(is_featured, false), COALESCE(is_active, true),
+			seo_title, seo_description, tags::text, created_at, updated_at
+		FROM products
+		ORDER BY sku`)
+	if err != nil {
+		return nil, fmt.Errorf("query products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []models.Product{}
+	for rows.Next() {
+		var p models.Product
+		var sizes []string
+		if err := rows.Scan(
+			&p.ID, &p.Name, &p.Slug, &p.Description, &p.Type, &p.Hashtag,
+			&p.SKU, &p.Price, &p.CompareAtPrice, &p.Quantity,
+			&sizes, &p.IsFeatured, &p.IsActive,
+			&p.SEOTitle, &p.SEODescription, &p.Tags, &p.CreatedAt, &p.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan product: %w", err)
+		}
+		p.AvailableSizes = make([]models.SizeType, len(sizes))
+		for i, size := range sizes {
+			p.AvailableSizes[i] = models.SizeType(size)
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// GetProductImages retrieves a product's images. When sizeType is set, only
+// images for that size and size-agnostic images are returned, size-specific first.
+func (r *ProductRepository) GetProductImages(ctx context.Context, productID int64, sizeType *models.SizeType) ([]models.ProductImage, error) {
 	query := `
 		SELECT
-			image_id, product_id, image_path, thumbnail_path, alt_text, sort_order, is_primary, created_at, updated_at
+			image_id, product_id, image_path, thumbnail_path, alt_text, size_type, sort_order, is_primary, created_at, updated_at
 		FROM product_images
 		WHERE product_id = $1
-		ORDER BY sort_order ASC, created_at ASC`
+		  AND ($2::size_type_enum IS NULL OR size_type = $2::size_type_enum OR size_type IS NULL)
+		ORDER BY
+			CASE WHEN $2::size_type_enum IS NOT NULL AND size_type IS NULL THEN 1 ELSE 0 END,
+			sort_order ASC, created_at ASC`
 
-	rows, err := r.db.Query(ctx, query, productID)
+	rows, err := r.db.Query(ctx, query, productID, sizeType)
 	if err != nil {
 		return nil, fmt.Errorf("query product images: %w", err)
 	}
 	defer rows.Close()
 
-	var images []models.ProductImage
+	images := []models.ProductImage{}
 	for rows.Next() {
-		var img models.ProductImage
-		var altText *string
-		var imagePath string
-		var thumbnailPath *string
+		img, err := scanProductImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, *img)
+	}
 
-		err := rows.Scan(
-			&img.ID, &img.ProductID, &imagePath, &thumbnailPath, &altText, &img.SortOrder, &img.IsPrimary, &img.CreatedAt, &img.UpdatedAt,
-		)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return images, nil
+}
+
+// ProductParams holds writable product fields. On update, nil fields are left
+// unchanged; AvailableSizes is only written when non-nil.
+type ProductParams struct {
+	Name           *string
+	Slug           *string // "" regenerates the slug from the name
+	Description    *string
+	Type           *models.ProductType
+	Hashtag        *string
+	SKU            *string
+	Price          *float64
+	CompareAtPrice *float64
+	// ClearCompareAtPrice removes the compare-at price.
+	ClearCompareAtPrice bool
+	Quantity       *int
+	AvailableSizes []models.SizeType
+	IsFeatured     *bool
+	IsActive       *bool
+	SEOTitle       *string
+	SEODescription *string
+	Tags           *string // JSON document
+	CreatedBy      *int64
+	PublishWindow
+}
+
+// ChangesAvailability reports whether p can change whether the product, or
+// one of its sizes, can be bought: its stock, sizes, type, active flag or
+// publishing window.
+func (p ProductParams) ChangesAvailability() bool {
+	w := p.PublishWindow
+	return p.Quantity != nil || p.AvailableSizes != nil || p.Type != nil || p.IsActive != nil ||
+		w.PublishAt != nil || w.ClearPublishAt || w.UnpublishAt != nil || w.ClearUnpublishAt
+}
+
+// productColumns maps each set field of params to its column assignment value.
+func (p ProductParams) productColumns() ([]string, []interface{}) {
+	var cols []string
+	var vals []interface{}
+	add := func(col string, val interface{}) {
+		cols = append(cols, col)
+		vals = append(vals, val)
+	}
+
+	if p.Name != nil {
+		add("product_name", *p.Name)
+	}
+	if p.Slug != nil && *p.Slug != "" {
+		add("slug", *p.Slug)
+	}
+	if p.Description != nil {
+		add("product_description", *p.Description)
+	}
+	if p.Type != nil {
+		add("product_type", *p.Type)
+	}
+	if p.Hashtag != nil {
+		add("hashtag", *p.Hashtag)
+	}
+	if p.SKU != nil {
+		add("sku", *p.SKU)
+	}
+	if p.Price != nil {
+		add("price", *p.Price)
+	}
+	if p.ClearCompareAtPrice {
+		add("compare_at_price", nil)
+	} else if p.CompareAtPrice != nil {
+		add("compare_at_price", *p.CompareAtPrice)
+	}
+	if p.Quantity != nil {
+		add("quantity", *p.Quantity)
+	}
+	if p.AvailableSizes != nil {
+		sizes := make([]string, len(p.AvailableSizes))
+		for i, size := range p.AvailableSizes {
+			sizes[i] = string(size)
+		}
+		add("available_sizes", sizes)
+	}
+	if p.IsFeatured != nil {
+		add("is_featured", *p.IsFeatured)
+	}
+	if p.IsActive != nil {
+		add("is_active", *p.IsActive)
+	}
+	if p.SEOTitle != nil {
+		add("seo_title", *p.SEOTitle)
+	}
+	if p.SEODescription != nil {
+		add("seo_description", *p.SEODescription)
+	}
+	if p.Tags != nil {
+		add("tags", *p.Tags)
+	}
+	if p.CreatedBy != nil {
+		add("created_by", *p.CreatedBy)
+	}
+	p.PublishWindow.addColumns(add)
+	return cols, vals
+}
+
+// productPlaceholder renders a bind parameter with the cast a column needs.
+func productPlaceholder(col string, n int) string {
+	switch col {
+	case "available_sizes":
+		return fmt.Sprintf("$%d::text[]::size_type_enum[]", n)
+	case "tags":
+		return fmt.Sprintf("$%d::text::jsonb", n)
+	default:
+		return fmt.Sprintf("$%d", n)
+	}
+}
+
+// CreateProduct inserts a product and returns its ID. Each available size
+// gets a variant at the product's price, and the quantity is split across
+// them (see splitStockSQL); bundles take no sizes.
+// Without an explicit slug, one is generated from the name. Name and SKU are
+// required.
+func (r *ProductRepository) CreateProduct(ctx context.Context, params ProductParams) (int64, error) {
+	if params.Type != nil && *params.Type == models.ProductTypeBundle && len(params.AvailableSizes) > 0 {
+		return 0, ErrBundleSized
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	slug, err := resolveSlug(ctx, tx, params.Slug, 0, *params.Name, *params.SKU)
+	if err != nil {
+		return 0, err
+	}
+	params.Slug = &slug
+
+	cols, vals := params.productColumns()
+	placeholders := make([]string, len(cols))
+	for i, col := range cols {
+		placeholders[i] = productPlaceholder(col, i+1)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO products (%s)
+		VALUES (%s)
+		RETURNING product_id`,
+		strings.Join(cols, ", "), strings.Join(placeholders, ", "))
+
+	var id int64
+	if err := tx.QueryRow(ctx, query, vals...).Scan(&id); err != nil {
+		return 0, fmt.Errorf("insert product: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO product_variants (product_id, size_type, sku_suffix, price, compare_at_price, quantity)
+		SELECT p.product_id, s.size_type, '-' || s.size_type::text, p.price, p.compare_at_price,
+		       `+splitStockSQL("COALESCE(p.quantity, 0)", "s.n", "cardinality(p.available_sizes)")+`
+		FROM products p
+		CROSS JOIN LATERAL unnest(p.available_sizes) WITH ORDINALITY AS s(size_type, n)
+		WHERE p.product_id = $1`, id); err != nil {
+		return 0, fmt.Errorf("insert product variants: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateProduct applies a partial update to a product. Renaming keeps the
+// slug; a changed slug leaves a redirect from the old one. Making a product a
+// bundle follows the rules of SetBundleComponents and CreateProduct. Price
+// and compare-at changes reach the variants (see applyToVariants), and
+// variants follow AvailableSizes (see syncVariantSizes).
+func (r *ProductRepository) UpdateProduct(ctx context.Context, productID int64, params ProductParams) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if params.Slug != nil {
+		var oldSlug *string
+		var name, sku string
+		err := tx.QueryRow(ctx, `
+			SELECT slug, product_name, sku FROM products WHERE product_id = $1 FOR UPDATE`,
+			productID).Scan(&oldSlug, &name, &sku)
 		if err != nil {
-			return nil, fmt.Errorf("scan product image: %w", err)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("lock product: %w", err)
+		}
+		if params.Name != nil {
+			name = *params.Name
+		}
+		if params.SKU != nil {
+			sku = *params.SKU
 		}
 
-		img.URL = imagePath
-		if thumbnailPath != nil {
-			img.ThumbnailURL = *thumbnailPath
+		slug, err := resolveSlug(ctx, tx, params.Slug, productID, name, sku)
+		if err != nil {
+			return err
 		}
-		if altText != nil {
-			img.AltText = *altText
+		if oldSlug != nil && *oldSlug == slug {
+			params.Slug = nil
 		} else {
-			img.AltText = ""
+			params.Slug = &slug
+			if err := recordSlugChange(ctx, tx, productID, oldSlug, slug); err != nil {
+				return err
+			}
+		}
+	}
+
+	if params.Type != nil || params.AvailableSizes != nil {
+		if err := checkBundleUpdate(ctx, tx, productID, params); err != nil {
+			return err
+		}
+	}
+
+	if err := applyToVariants(ctx, tx, productID, params); err != nil {
+		return err
+	}
+	if params.AvailableSizes != nil {
+		if err := syncVariantSizes(ctx, tx, productID, &params); err != nil {
+			return err
+		}
+	}
+
+	cols, vals := params.productColumns()
+	if len(cols) == 0 {
+		return tx.Commit(ctx)
+	}
+
+	sets := make([]string, len(cols))
+	for i, col := range cols {
+		sets[i] = col + " = " + productPlaceholder(col, i+1)
+	}
+	vals = append(vals, productID)
+
+	query := fmt.Sprintf(`UPDATE products SET %s WHERE product_id = $%d`, strings.Join(sets, ", "), len(vals))
+	cmd, err := tx.Exec(ctx, query, vals...)
+	if err != nil {
+		return fmt.Errorf("update product: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return tx.Commit(ctx)
+}
+
+// splitStockSQL shares total units over count sizes: each gets total/count,
+// and the sizes numbered (from 1) up to the remainder one more.
+func splitStockSQL(total, n, count string) string {
+	return fmt.Sprintf(`GREATEST(%[1]s, 0) / %[3]s + CASE WHEN %[2]s <= GREATEST(%[1]s, 0) %% %[3]s THEN 1 ELSE 0 END`, total, n, count)
+}
+
+// applyToVariants carries a product update over to its variants, before the
+// product row changes. Variants still at the product's old price or
+// compare-at price follow the new one, so per-size prices set through the
+// variant endpoint are kept; a compare-at price is only copied while it stays
+// above the variant's price. Quantity is not carried over: sized products
+// sell their variants' stock, which is set per size.
+func applyToVariants(ctx context.Context, tx pgx.Tx, productID int64, p ProductParams) error {
+	if p.Price != nil {
+		if _, err := tx.Exec(ctx, `
+			UPDATE product_variants v SET price = $2
+			FROM products p
+			WHERE p.product_id = v.product_id AND v.product_id = $1 AND v.price = p.price`,
+			productID, *p.Price); err != nil {
+			return fmt.Errorf("update variant prices: %w", err)
+		}
+	}
+	if p.CompareAtPrice != nil || p.ClearCompareAtPrice {
+		if _, err := tx.Exec(ctx, `
+			UPDATE product_variants v SET compare_at_price = $2
+			FROM products p
+			WHERE p.product_id = v.product_id AND v.product_id = $1
+			  AND v.compare_at_price IS NOT DISTINCT FROM p.compare_at_price
+			  AND ($2::numeric IS NULL OR $2::numeric > v.price)`,
+			productID, p.CompareAtPrice); err != nil {
+			return fmt.Errorf("update variant compare-at prices: %w", err)
+		}
+	}
+	return nil
+}
+
+// syncVariantSizes makes the product's variants match p.AvailableSizes, before
+// the product row changes. Removed sizes are deleted and taken out of carts,
+// as DeleteProductVariant does; added sizes get a variant at the product's
+// (new) price with no stock. A product gaining its first sizes has its stock
+// split across them as on create, and one losing its last keeps their total
+// stock unless p sets a quantity.
+func syncVariantSizes(ctx context.Context, tx pgx.Tx, productID int64, p *ProductParams) error {
+	sizes := make([]string, len(p.AvailableSizes))
+	for i, size := range p.AvailableSizes {
+		sizes[i] = string(size)
+	}
+
+	var variants int
+	err := tx.QueryRow(ctx, `
+		SELECT (SELECT COUNT(*) FROM product_variants WHERE product_id = p.product_id)
+		FROM products p
+		WHERE p.product_id = $1
+		FOR UPDATE`, productID).Scan(&variants)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("lock product: %w", err)
+	}
+
+	var removedStock int
+	if err := tx.QueryRow(ctx, `
+		WITH removed AS (
+			DELETE FROM product_variants
+			WHERE product_id = $1 AND NOT (size_type = ANY($2::text[]::size_type_enum[]))
+			RETURNING quantity
+		)
+		SELECT COALESCE(SUM(quantity), 0)::int FROM removed`,
+		productID, sizes).Scan(&removedStock); err != nil {
+		return fmt.Errorf("delete product variants: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM cart_items
+		WHERE product_id = $1 AND CASE
+			WHEN cardinality($2::text[]) = 0 THEN size_type IS NOT NULL
+			ELSE size_type IS NULL OR NOT (size_type = ANY($2::text[]::size_type_enum[]))
+		END`, productID, sizes); err != nil {
+		return fmt.Errorf("remove sizes from carts: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO product_variants (product_id, size_type, sku_suffix, price, compare_at_price, quantity)
+		SELECT p.product_id, s.size_type, '-' || s.size_type::text, COALESCE($3::numeric, p.price),
+		       CASE WHEN $5::boolean THEN NULL ELSE COALESCE($4::numeric, p.compare_at_price) END,
+		       CASE WHEN $6::boolean THEN `+splitStockSQL("COALESCE($7::int, p.quantity, 0)", "s.n", "cardinality($2::text[])")+` ELSE 0 END
+		FROM products p
+		CROSS JOIN LATERAL unnest($2::text[]::size_type_enum[]) WITH ORDINALITY AS s(size_type, n)
+		WHERE p.product_id = $1
+		ON CONFLICT (product_id, size_type) DO NOTHING`,
+		productID, sizes, p.Price, p.CompareAtPrice, p.ClearCompareAtPrice, variants == 0, p.Quantity); err != nil {
+		return fmt.Errorf("insert product variants: %w", err)
+	}
+
+	if len(sizes) == 0 && variants > 0 && p.Quantity == nil {
+		p.Quantity = &removedStock
+	}
+	return nil
+}
+
+// ProductImageParams holds writable image metadata. Nil fields are left unchanged.
+type ProductImageParams struct {
+	AltText   *string
+	SizeType  *models.SizeType
+	SortOrder *int
+	IsPrimary *bool
+}
+
+// CreateProductImage inserts an image. When the image is primary, any other
+// primary image of the same product and size is demoted first so that
+// idx_product_images_primary is never violated. A nil sortOrder appends the
+// image after the existing ones.
+func (r *ProductRepository) CreateProductImage(ctx context.Context, img *models.ProductImage, sortOrder *int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if img.IsPrimary {
+		if _, err := tx.Exec(ctx, `
+			UPDATE product_images SET is_primary = false
+			WHERE product_id = $1 AND size_type IS NOT DISTINCT FROM $2 AND is_primary`,
+			img.ProductID, img.SizeType); err != nil {
+			return fmt.Errorf("demote primary image: %w", err)
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO product_images (product_id, image_path, thumbnail_path, alt_text, size_type, sort_order, is_primary)
+		VALUES ($1, $2, $3, $4, $5,
+			COALESCE($6, (SELECT COALESCE(MAX(sort_order) + 1, 0) FROM product_images WHERE product_id = $1)),
+			$7)
+		RETURNING image_id, sort_order, created_at, updated_at`,
+		img.ProductID, img.URL, img.ThumbnailURL, img.AltText, img.SizeType, sortOrder, img.IsPrimary,
+	).Scan(&img.ID, &img.SortOrder, &img.CreatedAt, &img.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("insert product image: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UpdateProductImage applies a partial update to an image of the given product.
+func (r *ProductRepository) UpdateProductImage(ctx context.Context, productID, imageID int64, params ProductImageParams) (*models.ProductImage, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	img, err := scanProductImage(tx.QueryRow(ctx, `
+		SELECT image_id, product_id, image_path, thumbnail_path, alt_text, size_type, sort_order, is_primary, created_at, updated_at
+		FROM product_images
+		WHERE image_id = $1 AND product_id = $2
+		FOR UPDATE`, imageID, productID))
+	if err != nil {
+		return nil, err
+	}
+
+	if params.AltText != nil {
+		img.AltText = *params.AltText
+	}
+	if params.SizeType != nil {
+		img.SizeType = params.SizeType
+	}
+	if params.SortOrder != nil {
+		img.SortOrder = *params.SortOrder
+	}
+	if params.IsPrimary != nil {
+		img.IsPrimary = *params.IsPrimary
+	}
+
+	if img.IsPrimary {
+		if _, err := tx.Exec(ctx, `
+			UPDATE product_images SET is_primary = false
+			WHERE product_id = $1 AND size_type IS NOT DISTINCT FROM $2 AND is_primary AND image_id <> $3`,
+			productID, img.SizeType, imageID); err != nil {
+			return nil, fmt.Errorf("demote primary image: %w", err)
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE product_images
+		SET alt_text = $1, size_type = $2, sort_order = $3, is_primary = $4
+		WHERE image_id = $5
+		RETURNING updated_at`,
+		img.AltText, img.SizeType, img.SortOrder, img.IsPrimary, imageID,
+	).Scan(&img.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("update product image: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// ReorderProductImages sets sort_order to each image's position in imageIDs.
+// All of the product's images must be listed.
+func (r *ProductRepository) ReorderProductImages(ctx context.Context, productID int64, imageIDs []int64) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var count int
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM product_images WHERE product_id = $1`, productID).Scan(&count); err != nil {
+		return err
+	}
+	if count != len(imageIDs) {
+		return ErrNotFound
+	}
+
+	cmd, err := tx.Exec(ctx, `
+		UPDATE product_images pi
+		SET sort_order = o.ord - 1
+		FROM unnest($2::bigint[]) WITH ORDINALITY AS o(image_id, ord)
+		WHERE pi.image_id = o.image_id AND pi.product_id = $1`,
+		productID, imageIDs)
+	if err != nil {
+		return fmt.Errorf("reorder product images: %w", err)
+	}
+	if int(cmd.RowsAffected()) != len(imageIDs) {
+		return ErrNotFound
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteProductImage removes an image row and returns it so the caller can
+// clean up the underlying files.
+func (r *ProductRepository) DeleteProductImage(ctx context.Context, productID, imageID int64) (*models.ProductImage, error) {
+	return scanProductImage(r.db.QueryRow(ctx, `
+		DELETE FROM product_images
+		WHERE image_id = $1 AND product_id = $2
+		RETURNING image_id, product_id, image_path, thumbnail_path, alt_text, size_type, sort_order, is_primary, created_at, updated_at`,
+		imageID, productID))
+}
+
+// CountImagePathReferences reports how many images still use a file path.
+func (r *ProductRepository) CountImagePathReferences(ctx context.Context, path string) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM product_images WHERE image_path = $1 OR thumbnail_path = $1`, path).Scan(&count)
+	return count, err
+}
+
+func scanProductImage(row pgx.Row) (*models.ProductImage, error) {
+	var img models.ProductImage
+	var thumbnailPath *string
+	var altText *string
+	if err := row.Scan(
+		&img.ID, &img.ProductID, &img.URL, &thumbnailPath, &altText, &img.SizeType,
+		&img.SortOrder, &img.IsPrimary, &img.CreatedAt, &img.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan product image: %w", err)
+	}
+	if thumbnailPath != nil {
+		img.ThumbnailURL = *thumbnailPath
+	}
+	if altText != nil {
+		img.AltText = *altText
+	}
+	return &img, nil
+}
+
+// getCategoriesByProductIDs loads the categories each product is mapped to.
+func (r *ProductRepository) getCategoriesByProductIDs(ctx context.Context, productIDs []int64) (map[int64][]models.Category, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT pc.product_id, c.category_id, c.category_name, c.category_description,
+		       c.parent_category_id, COALESCE(c.is_active, true), c.publish_at, c.unpublish_at, c.created_at
+		FROM product_categories pc
+		JOIN categories c ON c.category_id = pc.category_id
+		WHERE pc.product_id = ANY($1)
+		ORDER BY pc.product_id, c.category_name`, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query product categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := make(map[int64][]models.Category)
+	for rows.Next() {
+		var productID int64
+		var cat models.Category
+		if err := rows.Scan(&productID, &cat.ID, &cat.Name, &cat.Description, &cat.ParentID, &cat.IsActive, &cat.PublishAt, &cat.UnpublishAt, &cat.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan product category: %w", err)
+		}
+		categories[productID] = append(categories[productID], cat)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return categories, nil
+}
+
+// SetProductCategories replaces a product's category mapping. Unknown
+// category IDs yield ErrNotFound.
+func (r *ProductRepository) SetProductCategories(ctx context.Context, productID int64, categoryIDs []int64) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM product_categories WHERE product_id = $1`, productID); err != nil {
+		return fmt.Errorf("clear product categories: %w", err)
+	}
+	cmd, err := tx.Exec(ctx, `
+		INSERT INTO product_categories (product_id, category_id)
+		SELECT $1, c.category_id FROM categories c WHERE c.category_id = ANY($2)`,
+		productID, categoryIDs)
+	if err != nil {
+		return fmt.Errorf("insert product categories: %w", err)
+	}
+	if int(cmd.RowsAffected()) != len(categoryIDs) {
+		return ErrNotFound
+	}
+	return tx.Commit(ctx)
+}
+
+// RefreshCoPurchases recomputes the product_copurchases view from order history.
+func (r *ProductRepository) RefreshCoPurchases(ctx context.Context) error {
+	if _, err := r.db.Exec(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY product_copurchases`); err != nil {
+		return fmt.Errorf("refresh product co-purchases: %w", err)
+	}
+	return nil
+}
+
+// GetRelatedProducts ranks other active products for productID. Being bought
+// in the same order scores up to 3, relative to the product's strongest
+// pairing; each directly shared category (at most two) and a matching hashtag
+// score 1. At least a quarter of the slots (one minimum) go to the other
+// product type when there are any, so faiachun pages also offer bags.
+// ErrNotFound is returned for unknown or unlisted products.
+func (r *ProductRepository) GetRelatedProducts(ctx context.Context, productID int64, limit int) ([]models.RelatedProduct, error) {
+	var exists bool
+	if err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM products p
+			WHERE p.product_id = $1 AND COALESCE(p.is_active, false) AND `+publishedCond("p")+`
+		)`, productID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("check product: %w", err)
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	rows, err := r.db.Query(ctx, `
+		WITH src AS (
+			SELECT product_type, lower(ltrim(COALESCE(hashtag, ''), '#')) AS tag
+			FROM products WHERE product_id = $1
+		), candidates AS (
+			SELECT p.product_id, p.product_name, COALESCE(p.slug, '') AS slug, p.product_type,
+			       p.price, p.compare_at_price, COALESCE(p.is_featured, false) AS is_featured, p.created_at,
+			       COALESCE(cp.order_count, 0) AS co_purchases,
+			       (SELECT COUNT(*)::int FROM product_categories pc
+			        JOIN product_categories spc ON spc.category_id = pc.category_id AND spc.product_id = $1
+			        WHERE pc.product_id = p.product_id) AS shared_categories,
+			       (src.tag <> '' AND lower(ltrim(COALESCE(p.hashtag, ''), '#')) = src.tag) AS same_hashtag,
+			       p.product_type <> src.product_type AS cross_type
+			FROM products p
+			CROSS JOIN src
+			LEFT JOIN product_copurchases cp ON cp.product_id = $1 AND cp.related_product_id = p.product_id
+			WHERE p.is_active AND `+publishedCond("p")+` AND p.product_id <> $1
+		), scored AS (
+			SELECT c.*,
+			       COALESCE(3.0 * c.co_purchases / NULLIF(MAX(c.co_purchases) OVER (), 0), 0)
+			       + LEAST(c.shared_categories, 2)
+			       + CASE WHEN c.same_hashtag THEN 1 ELSE 0 END AS score
+			FROM candidates c
+		), ranked AS (
+			SELECT s.*, ROW_NUMBER() OVER (
+			           PARTITION BY s.cross_type
+			           ORDER BY s.score DESC, s.is_featured DESC, s.created_at DESC, s.product_id) AS rn
+			FROM scored s
+		)
+		SELECT r.product_id, r.product_name, r.slug, r.product_type, r.price, r.compare_at_price,
+		       img.image_path, img.thumbnail_path,
+		       r.co_purchases, r.shared_categories, r.same_hashtag, r.score::float8, r.cross_type
+		FROM ranked r
+		LEFT JOIN LATERAL (
+			SELECT pi.image_path, pi.thumbnail_path
+			FROM product_images pi
+			WHERE pi.product_id = r.product_id
+			ORDER BY COALESCE(pi.is_primary, false) DESC, pi.sort_order, pi.image_id
+			LIMIT 1
+		) img ON true
+		WHERE r.rn <= $2
+		ORDER BY r.score DESC, r.is_featured DESC, r.created_at DESC, r.product_id`,
+		productID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query related products: %w", err)
+	}
+	defer rows.Close()
+
+	var ranked []models.RelatedProduct
+	var crossType []bool
+	crossCount := 0
+	for rows.Next() {
+		var p models.RelatedProduct
+		var cross bool
+		if err := rows.Scan(
+			&p.ID, &p.Name, &p.Slug, &p.Type, &p.Price, &p.CompareAtPrice,
+			&p.ImageURL, &p.ThumbnailURL,
+			&p.CoPurchaseCount, &p.SharedCategories, &p.SameHashtag, &p.Score, &cross,
+		); err != nil {
+			return nil, fmt.Errorf("scan related product: %w", err)
 		}
+		ranked = append(ranked, p)
+		crossType = append(crossType, cross)
+		if cross {
+			crossCount++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
 
-		images = append(images, img)
+	reserved := limit / 4
+	if reserved < 1 {
+		reserved = 1
+	}
+	if reserved > crossCount {
+		reserved = crossCount
 	}
+	sameQuota := limit - reserved
 
+	related := []models.RelatedProduct{}
+	sameTaken := 0
+	for i, p := range ranked {
+		if len(related) == limit {
+			break
+		}
+		if !crossType[i] {
+			if sameTaken == sameQuota {
+				continue
+			}
+			sameTaken++
+		}
+		related = append(related, p)
+	}
+
+	relatedIDs := make([]int64, len(related))
+	for i, p := range related {
+		relatedIDs[i] = p.ID
+	}
+	prices, err := getRecentPrices(ctx, r.db, relatedIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range related {
+		p := &related[i]
+		p.CompareAtPrice = prices[priceKey{p.ID, 0}].CompareAt(p.Price, p.CompareAtPrice)
+	}
+	return related, nil
+}
+
+// Suggest returns up to limit active product names, hashtags and active
+// categories starting with prefix (case-insensitive). Each lookup is a range
+// scan on a prefix index.
+func (r *ProductRepository) Suggest(ctx context.Context, prefix string, limit int) (*models.SearchSuggestions, error) {
+	lower := strings.ToLower(prefix)
+	upper := lower + "\U0010FFFF"
+	suggestions := &models.SearchSuggestions{
+		Products:   []models.ProductSuggestion{},
+		Hashtags:   []string{},
+		Categories: []models.CategorySuggestion{},
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT product_id, product_name, COALESCE(slug, '')
+		FROM products
+		WHERE is_active AND `+publishedCond("products")+`
+		  AND lower(product_name) COLLATE "C" >= $1 AND lower(product_name) COLLATE "C" < $2
+		ORDER BY is_featured DESC, product_name
+		LIMIT $3`, lower, upper, limit)
+	if err != nil {
+		return nil, fmt.Errorf("suggest products: %w", err)
+	}
+	for rows.Next() {
+		var p models.ProductSuggestion
+		if err := rows.Scan(&p.ID, &p.Name, &p.Slug); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan product suggestion: %w", err)
+		}
+		suggestions.Products = append(suggestions.Products, p)
+	}
+	rows.Close()
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("rows error: %w", err)
 	}
 
-	return images, nil
-}
\ No newline at end of file
+	// Hashtags match with or without the leading '#'. A prefix of only '#'
+	// would match every hashtag, so it suggests none.
+	if tagLower := strings.TrimLeft(lower, "#"); tagLower != "" {
+		rows, err = r.db.Query(ctx, `
+			SELECT hashtag
+			FROM products
+			WHERE is_active AND `+publishedCond("products")+` AND hashtag IS NOT NULL
+			  AND lower(ltrim(hashtag, '#')) COLLATE "C" >= $1 AND lower(ltrim(hashtag, '#')) COLLATE "C" < $2
+			GROUP BY hashtag
+			ORDER BY COUNT(*) DESC, hashtag
+			LIMIT $3`, tagLower, tagLower+"\U0010FFFF", limit)
+		if err != nil {
+			return nil, fmt.Errorf("suggest hashtags: %w", err)
+		}
+		for rows.Next() {
+			var tag string
+			if err := rows.Scan(&tag); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan hashtag suggestion: %w", err)
+			}
+			suggestions.Hashtags = append(suggestions.Hashtags, tag)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("rows error: %w", err)
+		}
+	}
+
+	rows, err = r.db.Query(ctx, `
+		SELECT category_id, category_name
+		FROM categories
+		WHERE COALESCE(is_active, true) AND `+publishedCond("categories")+`
+		  AND lower(category_name) COLLATE "C" >= $1 AND lower(category_name) COLLATE "C" < $2
+		ORDER BY category_name
+		LIMIT $3`, lower, upper, limit)
+	if err != nil {
+		return nil, fmt.Errorf("suggest categories: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c models.CategorySuggestion
+		if err := rows.Scan(&c.ID, &c.Name); err != nil {
+			return nil, fmt.Errorf("scan category suggestion: %w", err)
+		}
+		suggestions.Categories = append(suggestions.Categories, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// searchFallbackTerms splits a websearch-style query into the terms the
+// substring fallback must all find, skipping excluded terms and OR.
+func searchFallbackTerms(query string) []string {
+	var terms []string
+	for _, field := range strings.Fields(query) {
+		field = strings.Trim(field, `"`)
+		if field == "" || strings.HasPrefix(field, "-") || strings.EqualFold(field, "or") {
+			continue
+		}
+		terms = append(terms, field)
+	}
+	return terms
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ErrVariantUnavailable is returned when a product is sold per size and the
+// requested size is not one of its active variants.
+var ErrVariantUnavailable = errors.New("size not available for this product")
+
+// ErrProductUnavailable is returned when buying a product that is inactive or
+// outside its publishing window.
+var ErrProductUnavailable = errors.New("product not available")
+
+// ErrInsufficientStock is returned when a variant has fewer units than requested.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+const productVariantColumns = `
+	v.variant_id, v.product_id, v.size_type, v.sku_suffix, p.sku || v.sku_suffix,
+	v.price, v.compare_at_price, v.quantity, COALESCE(v.is_active, true), v.created_at, v.updated_at`
+
+// GetProductVariants retrieves a product's variants in size order.
+func (r *ProductRepository) GetProductVariants(ctx context.Context, productID int64) ([]models.ProductVariant, error) {
+	variants, err := r.getVariantsByProductIDs(ctx, []int64{productID})
+	if err != nil {
+		return nil, err
+	}
+	if variants[productID] == nil {
+		return []models.ProductVariant{}, nil
+	}
+	return variants[productID], nil
+}
+
+// getVariantsByProductIDs loads the variants of several products at once.
+func (r *ProductRepository) getVariantsByProductIDs(ctx context.Context, productIDs []int64) (map[int64][]models.ProductVariant, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT`+productVariantColumns+`
+		FROM product_variants v
+		JOIN products p ON p.product_id = v.product_id
+		WHERE v.product_id = ANY($1)
+		ORDER BY v.product_id, v.size_type`, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query product variants: %w", err)
+	}
+	defer rows.Close()
+
+	variants := make(map[int64][]models.ProductVariant)
+	for rows.Next() {
+		v, err := scanProductVariant(rows)
+		if err != nil {
+			return nil, err
+		}
+		variants[v.ProductID] = append(variants[v.ProductID], *v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return variants, nil
+}
+
+// liveStockJoins joins the variant v for the size saved on row alias (a
+// table with product_id and size_type, joined after products p) and
+// stock.quantity, the units that can be bought now. A size sells its
+// variant's quantity while the variant is active; no size means the total
+// over active variants; products without variants use products.quantity,
+// and bundles the stock their components allow.
+func liveStockJoins(alias string) string {
+	return strings.NewReplacer("{a}", alias).Replace(`
+	LEFT JOIN product_variants v ON v.product_id = {a}.product_id AND v.size_type = {a}.size_type
+	LEFT JOIN LATERAL (
+		SELECT COUNT(*) AS variant_count,
+		       COALESCE(SUM(pv.quantity) FILTER (WHERE COALESCE(pv.is_active, false)), 0)::int AS active_quantity
+		FROM product_variants pv
+		WHERE pv.product_id = {a}.product_id
+	) vs ON true
+	CROSS JOIN LATERAL (
+		SELECT CASE
+			WHEN p.product_type = 'bundle' THEN ` + bundleStockSQL("p") + `
+			WHEN v.variant_id IS NOT NULL THEN CASE WHEN COALESCE(v.is_active, false) THEN v.quantity ELSE 0 END
+			WHEN vs.variant_count > 0 AND {a}.size_type IS NULL THEN vs.active_quantity
+			WHEN vs.variant_count > 0 THEN 0
+			ELSE COALESCE(p.quantity, 0)
+		END AS quantity
+	) stock`)
+}
+
+// PublishWindow holds changes to publish_at and unpublish_at. Nil times are
+// left unchanged unless the matching Clear flag resets them to NULL.
+type PublishWindow struct {
+	PublishAt        *time.Time
+	ClearPublishAt   bool
+	UnpublishAt      *time.Time
+	ClearUnpublishAt bool
+}
+
+// addColumns passes the window's changes to a column setter.
+func (w PublishWindow) addColumns(add func(col string, val interface{})) {
+	if w.ClearPublishAt {
+		add("publish_at", nil)
+	} else if w.PublishAt != nil {
+		add("publish_at", *w.PublishAt)
+	}
+	if w.ClearUnpublishAt {
+		add("unpublish_at", nil)
+	} else if w.UnpublishAt != nil {
+		add("unpublish_at", *w.UnpublishAt)
+	}
+}
+
+// publishedCond is the SQL condition for a row of alias (a product, category
+// or collection) being inside its publishing window.
+func publishedCond(alias string) string {
+	return strings.NewReplacer("{a}", alias).Replace(
+		`(({a}.publish_at IS NULL OR {a}.publish_at <= CURRENT_TIMESTAMP)
+		AND ({a}.unpublish_at IS NULL OR {a}.unpublish_at > CURRENT_TIMESTAMP))`)
+}
+
+// getRatingsByProductIDs averages the approved reviews of each product.
+// Products without approved reviews are absent from the map.
+func (r *ProductRepository) getRatingsByProductIDs(ctx context.Context, productIDs []int64) (map[int64]models.ProductRating, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT product_id, ROUND(AVG(rating), 2)::float8, COUNT(*)::int
+		FROM product_reviews
+		WHERE product_id = ANY($1) AND status = 'approved'
+		GROUP BY product_id`, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query product ratings: %w", err)
+	}
+	defer rows.Close()
+
+	ratings := make(map[int64]models.ProductRating)
+	for rows.Next() {
+		var productID int64
+		var rating models.ProductRating
+		if err := rows.Scan(&productID, &rating.Average, &rating.Count); err != nil {
+			return nil, fmt.Errorf("scan product rating: %w", err)
+		}
+		ratings[productID] = rating
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return ratings, nil
+}
+
+// priceKey identifies a product's own price (variantID 0) or a variant's.
+type priceKey struct {
+	productID int64
+	variantID int64
+}
+
+// getRecentPrices returns the recent prices of the given products and their
+// variants from price_history. Each history row applies until the next one.
+func getRecentPrices(ctx context.Context, db rowQuerier, productIDs []int64) (map[priceKey]models.RecentPrices, error) {
+	rows, err := db.Query(ctx, `
+		SELECT product_id, COALESCE(variant_id, 0)::bigint, MIN(price)::float8, MAX(price)::float8
+		FROM (
+			SELECT product_id, variant_id, price,
+			       LEAD(changed_at) OVER (PARTITION BY product_id, variant_id ORDER BY changed_at, price_history_id) AS ended_at
+			FROM price_history
+			WHERE product_id = ANY($1)
+		) h
+		WHERE ended_at IS NULL OR ended_at > CURRENT_TIMESTAMP - make_interval(days => $2)
+		GROUP BY product_id, variant_id`, productIDs, models.PriceHistoryDays)
+	if err != nil {
+		return nil, fmt.Errorf("query recent prices: %w", err)
+	}
+	defer rows.Close()
+
+	prices := make(map[priceKey]models.RecentPrices)
+	for rows.Next() {
+		var key priceKey
+		var rp models.RecentPrices
+		if err := rows.Scan(&key.productID, &key.variantID, &rp.Lowest, &rp.Highest); err != nil {
+			return nil, fmt.Errorf("scan recent prices: %w", err)
+		}
+		prices[key] = rp
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return prices, nil
+}
+
+// applyRecentPrices sets the lowest recent price of a product and its
+// variants and clears compare-at prices they did not sell at recently.
+func applyRecentPrices(p *models.ProductWithDetails, prices map[priceKey]models.RecentPrices) {
+	p.ListedCompareAtPrice = p.CompareAtPrice
+	if rp, ok := prices[priceKey{p.ID, 0}]; ok {
+		lowest := rp.Lowest
+		p.LowestRecentPrice = &lowest
+	}
+	p.CompareAtPrice = prices[priceKey{p.ID, 0}].CompareAt(p.Price, p.CompareAtPrice)
+
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		if rp, ok := prices[priceKey{p.ID, v.ID}]; ok {
+			lowest := rp.Lowest
+			v.LowestRecentPrice = &lowest
+		}
+		v.CompareAtPrice = prices[priceKey{p.ID, v.ID}].CompareAt(v.Price, v.CompareAtPrice)
+	}
+}
+
+// ProductVariantParams holds writable variant fields. Nil fields are left
+// unchanged on update; on insert they default to "-<size>", the product price,
+// no compare-at price, zero stock and active.
+type ProductVariantParams struct {
+	SKUSuffix      *string
+	Price          *float64
+	CompareAtPrice *float64
+	// ClearCompareAtPrice removes the compare-at price.
+	ClearCompareAtPrice bool
+	Quantity       *int
+	IsActive       *bool
+}
+
+// UpsertProductVariant creates or updates the variant for a product size and
+// keeps products.available_sizes in step.
+func (r *ProductRepository) UpsertProductVariant(ctx context.Context, productID int64, sizeType models.SizeType, params ProductVariantParams) (*models.ProductVariant, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var variantID int64
+	err = tx.QueryRow(ctx, `
+		INSERT INTO product_variants (product_id, size_type, sku_suffix, price, compare_at_price, quantity, is_active)
+		SELECT p.product_id, $2::size_type_enum, COALESCE($3::varchar, '-' || $2::text),
+			COALESCE($4::numeric, p.price), $5::numeric, COALESCE($6::int, 0), COALESCE($7::boolean, true)
+		FROM products p
+		WHERE p.product_id = $1
+		ON CONFLICT (product_id, size_type) DO UPDATE SET
+			sku_suffix = COALESCE($3::varchar, product_variants.sku_suffix),
+			price = COALESCE($4::numeric, product_variants.price),
+			compare_at_price = CASE WHEN $8::boolean THEN NULL ELSE COALESCE($5::numeric, product_variants.compare_at_price) END,
+			quantity = COALESCE($6::int, product_variants.quantity),
+			is_active = COALESCE($7::boolean, product_variants.is_active)
+		RETURNING variant_id`,
+		productID, sizeType, params.SKUSuffix, params.Price, params.CompareAtPrice, params.Quantity, params.IsActive,
+		params.ClearCompareAtPrice,
+	).Scan(&variantID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("upsert product variant: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE products SET available_sizes = array_append(COALESCE(available_sizes, '{}'), $2::size_type_enum)
+		WHERE product_id = $1 AND NOT ($2::size_type_enum = ANY(COALESCE(available_sizes, '{}')))`,
+		productID, sizeType); err != nil {
+		return nil, fmt.Errorf("update available sizes: %w", err)
+	}
+
+	v, err := scanProductVariant(tx.QueryRow(ctx, `
+		SELECT`+productVariantColumns+`
+		FROM product_variants v
+		JOIN products p ON p.product_id = v.product_id
+		WHERE v.variant_id = $1`, variantID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// DeleteProductVariant removes a product size, drops it from available_sizes
+// and takes it out of any carts. Past order items keep their snapshot.
+func (r *ProductRepository) DeleteProductVariant(ctx context.Context, productID int64, sizeType models.SizeType) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `DELETE FROM product_variants WHERE product_id = $1 AND size_type = $2`, productID, sizeType)
+	if err != nil {
+		return fmt.Errorf("delete product variant: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE products SET available_sizes = array_remove(available_sizes, $2::size_type_enum)
+		WHERE product_id = $1`, productID, sizeType); err != nil {
+		return fmt.Errorf("update available sizes: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM cart_items WHERE product_id = $1 AND size_type = $2`, productID, sizeType); err != nil {
+		return fmt.Errorf("remove variant from carts: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func scanProductVariant(row pgx.Row) (*models.ProductVariant, error) {
+	var v models.ProductVariant
+	if err := row.Scan(
+		&v.ID, &v.ProductID, &v.SizeType, &v.SKUSuffix, &v.SKU,
+		&v.Price, &v.CompareAtPrice, &v.Quantity, &v.IsActive, &v.CreatedAt, &v.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan product variant: %w", err)
+	}
+	v.ListedCompareAtPrice = v.CompareAtPrice
+	return &v, nil
+}
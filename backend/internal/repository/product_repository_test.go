@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+func TestChangesAvailability(t *testing.T) {
+	quantity, active, name, price := 1, false, "Renamed", 10.0
+	at := time.Now()
+	tests := []struct {
+		name   string
+		params ProductParams
+		want   bool
+	}{
+		{"nothing", ProductParams{}, false},
+		{"name and price", ProductParams{Name: &name, Price: &price}, false},
+		{"quantity", ProductParams{Quantity: &quantity}, true},
+		{"sizes", ProductParams{AvailableSizes: []models.SizeType{}}, true},
+		{"active", ProductParams{IsActive: &active}, true},
+		{"publish at", ProductParams{PublishWindow: PublishWindow{PublishAt: &at}}, true},
+		{"clear unpublish at", ProductParams{PublishWindow: PublishWindow{ClearUnpublishAt: true}}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.params.ChangesAvailability(); got != tt.want {
+			t.Errorf("%s: ChangesAvailability() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateProductSizes(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewProductRepository(pool)
+
+	vRect, square, bigSquare := models.SizeTypeVRect, models.SizeTypeSquare, models.SizeTypeBigSquare
+	id := createTestProduct(t, repo, "FC-1", models.ProductTypeFaiachun, 20, 5, vRect, square)
+
+	// Adding a size starts it empty; removing one drops its variant.
+	if err := repo.UpdateProduct(ctx, id, ProductParams{AvailableSizes: []models.SizeType{vRect, bigSquare}}); err != nil {
+		t.Fatalf("UpdateProduct(sizes): %v", err)
+	}
+	stock := variantStock(t, pool, id)
+	if len(stock) != 2 || stock[vRect] != 3 || stock[bigSquare] != 0 {
+		t.Errorf("after resizing: variant stock = %v, want v-rect 3, big-square 0", stock)
+	}
+
+	// Quantity is per size once a product has sizes.
+	quantity := 100
+	if err := repo.UpdateProduct(ctx, id, ProductParams{Quantity: &quantity}); err != nil {
+		t.Fatalf("UpdateProduct(quantity): %v", err)
+	}
+	if got := variantStock(t, pool, id); got[vRect] != 3 || got[bigSquare] != 0 {
+		t.Errorf("after quantity: variant stock = %v, want it unchanged", got)
+	}
+
+	// Removing every size moves their stock back onto the product.
+	if err := repo.UpdateProduct(ctx, id, ProductParams{AvailableSizes: []models.SizeType{}}); err != nil {
+		t.Fatalf("UpdateProduct(no sizes): %v", err)
+	}
+	if got := variantStock(t, pool, id); len(got) != 0 {
+		t.Errorf("without sizes: variant stock = %v, want none", got)
+	}
+	if got := productStock(t, pool, id); got != 3 {
+		t.Errorf("without sizes: quantity = %d, want 3", got)
+	}
+
+	// Sizing a product without sizes splits its stock.
+	bag := createTestProduct(t, repo, "BAG-1", models.ProductTypeBag, 50, 7)
+	if err := repo.UpdateProduct(ctx, bag, ProductParams{AvailableSizes: []models.SizeType{vRect, square}}); err != nil {
+		t.Fatalf("UpdateProduct(bag sizes): %v", err)
+	}
+	if got := variantStock(t, pool, bag); got[vRect] != 4 || got[square] != 3 {
+		t.Errorf("sized bag: variant stock = %v, want v-rect 4, square 3", got)
+	}
+}
+
+func TestPublishWindow(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewProductRepository(pool)
+
+	listed := createTestProduct(t, repo, "BAG-1", models.ProductTypeBag, 50, 3)
+	scheduled := createTestProduct(t, repo, "BAG-2", models.ProductTypeBag, 50, 3)
+	ended := createTestProduct(t, repo, "BAG-3", models.ProductTypeBag, 50, 3)
+
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Hour)
+	if err := repo.UpdateProduct(ctx, scheduled, ProductParams{PublishWindow: PublishWindow{PublishAt: &future}}); err != nil {
+		t.Fatalf("UpdateProduct(publish_at): %v", err)
+	}
+	if err := repo.UpdateProduct(ctx, ended, ProductParams{PublishWindow: PublishWindow{UnpublishAt: &past}}); err != nil {
+		t.Fatalf("UpdateProduct(unpublish_at): %v", err)
+	}
+
+	products, total, err := repo.ListProducts(ctx, ProductFilters{}, ProductSort{Field: "created_at", Order: "desc"}, 1, 50)
+	if err != nil {
+		t.Fatalf("ListProducts: %v", err)
+	}
+	if total != 1 || len(products) != 1 || products[0].ID != listed {
+		t.Errorf("ListProducts = %d products (total %d), want only product %d", len(products), total, listed)
+	}
+
+	for _, id := range []int64{scheduled, ended} {
+		if _, err := repo.GetRelatedProducts(ctx, id, 4); err != ErrNotFound {
+			t.Errorf("GetRelatedProducts(%d): err = %v, want %v", id, err, ErrNotFound)
+		}
+		if err := checkVariantStock(ctx, pool, id, nil, 1); err != ErrProductUnavailable {
+			t.Errorf("checkVariantStock(%d): err = %v, want %v", id, err, ErrProductUnavailable)
+		}
+	}
+	if _, err := repo.GetRelatedProducts(ctx, listed, 4); err != nil {
+		t.Errorf("GetRelatedProducts(%d): %v", listed, err)
+	}
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// ErrSlugTaken is returned when a requested slug belongs to another product,
+// either as its current slug or as one it redirects from.
+var ErrSlugTaken = errors.New("slug already in use")
+
+// baseSlug derives a slug from the product name, falling back to the SKU
+// when the name has nothing to transliterate.
+func baseSlug(name, sku string) string {
+	if slug := models.Slugify(name); slug != "" {
+		return slug
+	}
+	if slug := models.Slugify(sku); slug != "" {
+		return slug
+	}
+	return "product"
+}
+
+// uniqueSlug returns base, or base with the lowest free numeric suffix, such
+// that no other product uses it as a current or previous slug.
+func uniqueSlug(ctx context.Context, tx pgx.Tx, base string, productID int64) (string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT slug FROM products
+		WHERE (slug = $1 OR slug LIKE $2) AND product_id <> $3
+		UNION
+		SELECT old_slug FROM product_slug_redirects
+		WHERE (old_slug = $1 OR old_slug LIKE $2) AND product_id <> $3`,
+		base, escapeLike(base)+"-%", productID)
+	if err != nil {
+		return "", fmt.Errorf("query slugs: %w", err)
+	}
+	defer rows.Close()
+
+	taken := make(map[string]bool)
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return "", fmt.Errorf("scan slug: %w", err)
+		}
+		taken[slug] = true
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("rows error: %w", err)
+	}
+
+	slug := base
+	for n := 2; taken[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return slug, nil
+}
+
+// resolveSlug picks the slug to store for a product. A non-empty requested
+// slug is used as-is and fails with ErrSlugTaken when another product holds
+// it; otherwise a unique slug is generated from name and sku.
+func resolveSlug(ctx context.Context, tx pgx.Tx, requested *string, productID int64, name, sku string) (string, error) {
+	if requested != nil && *requested != "" {
+		slug, err := uniqueSlug(ctx, tx, *requested, productID)
+		if err != nil {
+			return "", err
+		}
+		if slug != *requested {
+			return "", ErrSlugTaken
+		}
+		return slug, nil
+	}
+	return uniqueSlug(ctx, tx, baseSlug(name, sku), productID)
+}
+
+// recordSlugChange keeps oldSlug resolving to the product and drops any
+// redirect the product is taking its slug back from.
+func recordSlugChange(ctx context.Context, tx pgx.Tx, productID int64, oldSlug *string, newSlug string) error {
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM product_slug_redirects WHERE old_slug = $1`, newSlug); err != nil {
+		return fmt.Errorf("delete slug redirect: %w", err)
+	}
+	if oldSlug == nil || *oldSlug == "" {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO product_slug_redirects (old_slug, product_id)
+		VALUES ($1, $2)
+		ON CONFLICT (old_slug) DO UPDATE
+		SET product_id = EXCLUDED.product_id, created_at = CURRENT_TIMESTAMP`,
+		*oldSlug, productID); err != nil {
+		return fmt.Errorf("insert slug redirect: %w", err)
+	}
+	return nil
+}
+
+// ResolveProductSlug looks up the product for slug. currentSlug differs from
+// slug when slug is a previous slug that now redirects.
+func (r *ProductRepository) ResolveProductSlug(ctx context.Context, slug string) (productID int64, currentSlug string, err error) {
+	err = r.db.QueryRow(ctx, `
+		SELECT product_id, slug FROM products WHERE slug = $1`, slug).Scan(&productID, &currentSlug)
+	if err == nil {
+		return productID, currentSlug, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, "", fmt.Errorf("query product slug: %w", err)
+	}
+
+	err = r.db.QueryRow(ctx, `
+		SELECT p.product_id, p.slug
+		FROM product_slug_redirects r
+		JOIN products p ON p.product_id = r.product_id
+		WHERE r.old_slug = $1 AND p.slug IS NOT NULL`, slug).Scan(&productID, &currentSlug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, "", ErrNotFound
+		}
+		return 0, "", fmt.Errorf("query slug redirect: %w", err)
+	}
+	return productID, currentSlug, nil
+}
+
+// BackfillSlugs generates slugs for products that have none, such as rows
+// created before slugs existed. It returns the number of products updated.
+func (r *ProductRepository) BackfillSlugs(ctx context.Context) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	type pending struct {
+		id        int64
+		name, sku string
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT product_id, product_name, sku FROM products
+		WHERE slug IS NULL
+		ORDER BY product_id
+		FOR UPDATE`)
+	if err != nil {
+		return 0, fmt.Errorf("query products without slug: %w", err)
+	}
+	var todo []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.name, &p.sku); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan product: %w", err)
+		}
+		todo = append(todo, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows error: %w", err)
+	}
+
+	for _, p := range todo {
+		slug, err := uniqueSlug(ctx, tx, baseSlug(p.name, p.sku), p.id)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE products SET slug = $1 WHERE product_id = $2`, slug, p.id); err != nil {
+			return 0, fmt.Errorf("set product slug: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(todo), nil
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// ErrNotVerifiedBuyer is returned when a client reviews a product that was
+// never delivered to them.
+var ErrNotVerifiedBuyer = errors.New("product not delivered to client")
+
+// ReviewRepository handles database operations for product reviews.
+type ReviewRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReviewRepository(db *pgxpool.Pool) *ReviewRepository {
+	return &ReviewRepository{db: db}
+}
+
+const reviewColumns = `
+	r.review_id, r.product_id, r.client_id, r.order_id, c.username, r.rating, r.body,
+	r.photo_path, r.status, r.moderated_at, r.created_at`
+
+func scanReview(row pgx.Row, extra ...interface{}) (*models.ProductReview, error) {
+	var rv models.ProductReview
+	dest := append([]interface{}{
+		&rv.ID, &rv.ProductID, &rv.ClientID, &rv.OrderID, &rv.ReviewerName, &rv.Rating, &rv.Body,
+		&rv.PhotoURL, &rv.Status, &rv.ModeratedAt, &rv.CreatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan review: %w", err)
+	}
+	return &rv, nil
+}
+
+// FindDeliveredOrder returns the latest delivered order of the client that
+// contains the product, or ErrNotVerifiedBuyer.
+func (r *ReviewRepository) FindDeliveredOrder(ctx context.Context, clientID, productID int64) (int64, error) {
+	var orderID int64
+	err := r.db.QueryRow(ctx, `
+		SELECT o.order_id
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.order_id
+		WHERE o.client_id = $1 AND oi.product_id = $2 AND o.order_status = 'delivered'
+		ORDER BY o.created_at DESC
+		LIMIT 1`, clientID, productID).Scan(&orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrNotVerifiedBuyer
+		}
+		return 0, fmt.Errorf("find delivered order: %w", err)
+	}
+	return orderID, nil
+}
+
+// Create stores a pending review. A client can review each product once, so a
+// second review fails with a unique violation on (product_id, client_id).
+func (r *ReviewRepository) Create(ctx context.Context, review *models.ProductReview) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO product_reviews (product_id, client_id, order_id, rating, body, photo_path)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING review_id, status, created_at`,
+		review.ProductID, review.ClientID, review.OrderID, review.Rating, review.Body, review.PhotoURL,
+	).Scan(&review.ID, &review.Status, &review.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert review: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a review regardless of its status.
+func (r *ReviewRepository) GetByID(ctx context.Context, reviewID int64) (*models.ProductReview, error) {
+	return scanReview(r.db.QueryRow(ctx, `
+		SELECT`+reviewColumns+`
+		FROM product_reviews r
+		JOIN client c ON c.client_id = r.client_id
+		WHERE r.review_id = $1`, reviewID))
+}
+
+// ListApproved returns a page of a product's approved reviews, newest first,
+// with the rating over all of them. Unknown products give ErrNotFound.
+func (r *ReviewRepository) ListApproved(ctx context.Context, productID int64, limit, offset int) ([]models.ProductReview, models.ProductRating, error) {
+	var rating models.ProductRating
+	err := r.db.QueryRow(ctx, `
+		SELECT ROUND(AVG(r.rating), 2)::float8, COUNT(r.review_id)::int
+		FROM products p
+		LEFT JOIN product_reviews r ON r.product_id = p.product_id AND r.status = 'approved'
+		WHERE p.product_id = $1
+		GROUP BY p.product_id`, productID).Scan(&rating.Average, &rating.Count)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, rating, ErrNotFound
+		}
+		return nil, rating, fmt.Errorf("product rating: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT`+reviewColumns+`
+		FROM product_reviews r
+		JOIN client c ON c.client_id = r.client_id
+		WHERE r.product_id = $1 AND r.status = 'approved'
+		ORDER BY r.created_at DESC, r.review_id DESC
+		LIMIT $2 OFFSET $3`, productID, limit, offset)
+	if err != nil {
+		return nil, rating, fmt.Errorf("query reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []models.ProductReview{}
+	for rows.Next() {
+		rv, err := scanReview(rows)
+		if err != nil {
+			return nil, rating, err
+		}
+		reviews = append(reviews, *rv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, rating, fmt.Errorf("rows error: %w", err)
+	}
+	return reviews, rating, nil
+}
+
+// ReviewFilters narrows the moderation queue. Nil fields match everything.
+type ReviewFilters struct {
+	Status    *models.ReviewStatus
+	ProductID *int64
+}
+
+// ListForModeration returns a page of reviews for admins, oldest first so the
+// queue is worked in order, with the total matching count.
+func (r *ReviewRepository) ListForModeration(ctx context.Context, filters ReviewFilters, limit, offset int) ([]models.AdminProductReview, int, error) {
+	where := "TRUE"
+	var args []interface{}
+	if filters.Status != nil {
+		args = append(args, *filters.Status)
+		where += fmt.Sprintf(" AND r.status = $%d", len(args))
+	}
+	if filters.ProductID != nil {
+		args = append(args, *filters.ProductID)
+		where += fmt.Sprintf(" AND r.product_id = $%d", len(args))
+	}
+
+	var total int
+	if err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM product_reviews r WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count reviews: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`
+		SELECT`+reviewColumns+`, p.product_name, c.phone
+		FROM product_reviews r
+		JOIN client c ON c.client_id = r.client_id
+		JOIN products p ON p.product_id = r.product_id
+		WHERE %s
+		ORDER BY r.created_at, r.review_id
+		LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2),
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []models.AdminProductReview{}
+	for rows.Next() {
+		var ar models.AdminProductReview
+		rv, err := scanReview(rows, &ar.ProductName, &ar.ClientPhone)
+		if err != nil {
+			return nil, 0, err
+		}
+		ar.ProductReview = *rv
+		reviews = append(reviews, ar)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	return reviews, total, nil
+}
+
+// SetStatus records a moderation decision by an admin.
+func (r *ReviewRepository) SetStatus(ctx context.Context, reviewID int64, status models.ReviewStatus, adminID int64) (*models.ProductReview, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE product_reviews
+		SET status = $2, moderated_by = $3, moderated_at = CURRENT_TIMESTAMP
+		WHERE review_id = $1`, reviewID, status, adminID)
+	if err != nil {
+		return nil, fmt.Errorf("update review status: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrNotFound
+	}
+	return r.GetByID(ctx, reviewID)
+}
+
+// Delete removes a review and returns its photo URL, if any, so the caller
+// can delete the file.
+func (r *ReviewRepository) Delete(ctx context.Context, reviewID int64) (*string, error) {
+	var photoPath *string
+	err := r.db.QueryRow(ctx, `
+		DELETE FROM product_reviews WHERE review_id = $1 RETURNING photo_path`, reviewID).Scan(&photoPath)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("delete review: %w", err)
+	}
+	return photoPath, nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// ErrNoShippingRate is returned when no active rate covers a shipment.
+var ErrNoShippingRate = errors.New("no shipping rate for this shipment")
+
+// ShippingRateRepository handles database operations for shipping rates.
+type ShippingRateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewShippingRateRepository(db *pgxpool.Pool) *ShippingRateRepository {
+	return &ShippingRateRepository{db: db}
+}
+
+const shippingRateColumns = `
+	rate_id, rate_name, shipping_method, region, min_weight::float8, max_weight::float8,
+	base_fee::float8, fee_per_kg::float8, free_over::float8, priority, is_active, created_at, updated_at`
+
+func scanShippingRate(row pgx.Row) (*models.ShippingRate, error) {
+	var r models.ShippingRate
+	if err := row.Scan(
+		&r.ID, &r.Name, &r.Method, &r.Region, &r.MinWeight, &r.MaxWeight,
+		&r.BaseFee, &r.FeePerKg, &r.FreeOver, &r.Priority, &r.IsActive, &r.CreatedAt, &r.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan shipping rate: %w", err)
+	}
+	return &r, nil
+}
+
+// List returns all shipping rates, grouped by method.
+func (r *ShippingRateRepository) List(ctx context.Context) ([]models.ShippingRate, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT`+shippingRateColumns+`
+		FROM shipping_rates
+		ORDER BY shipping_method, region NULLS FIRST, min_weight, priority DESC, rate_id`)
+	if err != nil {
+		return nil, fmt.Errorf("query shipping rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := []models.ShippingRate{}
+	for rows.Next() {
+		rate, err := scanShippingRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, *rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return rates, nil
+}
+
+// Create adds a shipping rate.
+func (r *ShippingRateRepository) Create(ctx context.Context, rate models.ShippingRate) (*models.ShippingRate, error) {
+	return scanShippingRate(r.db.QueryRow(ctx, `
+		INSERT INTO shipping_rates (
+			rate_name, shipping_method, region, min_weight, max_weight, base_fee, fee_per_kg, free_over, priority, is_active
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING`+shippingRateColumns,
+		rate.Name, rate.Method, rate.Region, rate.MinWeight, rate.MaxWeight,
+		rate.BaseFee, rate.FeePerKg, rate.FreeOver, rate.Priority, rate.IsActive))
+}
+
+// Update replaces a shipping rate's settings.
+func (r *ShippingRateRepository) Update(ctx context.Context, rateID int64, rate models.ShippingRate) (*models.ShippingRate, error) {
+	return scanShippingRate(r.db.QueryRow(ctx, `
+		UPDATE shipping_rates SET
+			rate_name = $2, shipping_method = $3, region = $4, min_weight = $5, max_weight = $6,
+			base_fee = $7, fee_per_kg = $8, free_over = $9, priority = $10, is_active = $11
+		WHERE rate_id = $1
+		RETURNING`+shippingRateColumns,
+		rateID, rate.Name, rate.Method, rate.Region, rate.MinWeight, rate.MaxWeight,
+		rate.BaseFee, rate.FeePerKg, rate.FreeOver, rate.Priority, rate.IsActive))
+}
+
+// Delete removes a shipping rate. Orders keep the fee they were charged.
+func (r *ShippingRateRepository) Delete(ctx context.Context, rateID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM shipping_rates WHERE rate_id = $1`, rateID)
+	if err != nil {
+		return fmt.Errorf("delete shipping rate: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Find returns the rate for a shipment; see findShippingRate.
+func (r *ShippingRateRepository) Find(ctx context.Context, method models.ShippingMethod, regions []string, weight float64) (*models.ShippingRate, error) {
+	return findShippingRate(ctx, r.db, method, regions, weight)
+}
+
+// CartWeight returns the weight of a cart's contents in kg.
+func (r *ShippingRateRepository) CartWeight(ctx context.Context, cartID string) (float64, error) {
+	return cartWeight(ctx, r.db, cartID)
+}
+
+// findShippingRate picks the active rate of method whose weight range holds
+// weight. A rate for one of regions wins over a rate for everywhere, then
+// higher priority wins.
+func findShippingRate(ctx context.Context, db rowQuerier, method models.ShippingMethod, regions []string, weight float64) (*models.ShippingRate, error) {
+	if regions == nil {
+		regions = []string{}
+	}
+	rate, err := scanShippingRate(db.QueryRow(ctx, `
+		SELECT`+shippingRateColumns+`
+		FROM shipping_rates
+		WHERE is_active AND shipping_method = $1
+		  AND (region IS NULL OR lower(region) = ANY($2::text[]))
+		  AND min_weight <= $3::numeric AND (max_weight IS NULL OR max_weight > $3::numeric)
+		ORDER BY region IS NOT NULL DESC, priority DESC, rate_id
+		LIMIT 1`, method, regions, weight))
+	if err == ErrNotFound {
+		return nil, ErrNoShippingRate
+	}
+	return rate, err
+}
+
+// cartWeight sums products.weight times quantity over a cart's lines.
+// Products without a weight count as weightless.
+func cartWeight(ctx context.Context, db rowQuerier, cartID string) (float64, error) {
+	var weight float64
+	err := db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(COALESCE(p.weight, 0) * ci.quantity), 0)::float8
+		FROM cart_items ci
+		JOIN products p ON p.product_id = ci.product_id
+		WHERE ci.cart_id = $1`, cartID).Scan(&weight)
+	if err != nil {
+		return 0, fmt.Errorf("cart weight: %w", err)
+	}
+	return weight, nil
+}
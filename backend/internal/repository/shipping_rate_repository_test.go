@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+func TestFindShippingRate(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewShippingRateRepository(pool)
+
+	if _, err := pool.Exec(ctx, `DELETE FROM shipping_rates`); err != nil {
+		t.Fatalf("clear rates: %v", err)
+	}
+	kowloon := "Kowloon"
+	two := 2.0
+	rates := map[string]models.ShippingRate{
+		"light":    {MaxWeight: &two, BaseFee: 30},
+		"heavy":    {MinWeight: 2, BaseFee: 50},
+		"priority": {MaxWeight: &two, BaseFee: 25, Priority: 1},
+		"region":   {Region: &kowloon, BaseFee: 20},
+		"inactive": {Region: &kowloon, BaseFee: 10, Priority: 5},
+	}
+	ids := make(map[string]int64)
+	for name, rate := range rates {
+		rate.Name = name
+		rate.Method = models.ShippingMethodDelivery
+		rate.IsActive = name != "inactive"
+		created, err := repo.Create(ctx, rate)
+		if err != nil {
+			t.Fatalf("Create(%s): %v", name, err)
+		}
+		ids[name] = created.ID
+	}
+
+	tests := []struct {
+		name    string
+		method  models.ShippingMethod
+		regions []string
+		weight  float64
+		want    string
+	}{
+		{"higher priority wins", models.ShippingMethodDelivery, nil, 1, "priority"},
+		{"weight range", models.ShippingMethodDelivery, nil, 2, "heavy"},
+		{"region wins over priority", models.ShippingMethodDelivery, []string{"kowloon", "hong kong"}, 1, "region"},
+		{"other region", models.ShippingMethodDelivery, []string{"central"}, 1, "priority"},
+	}
+	for _, tt := range tests {
+		rate, err := findShippingRate(ctx, pool, tt.method, tt.regions, tt.weight)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if rate.ID != ids[tt.want] {
+			t.Errorf("%s: got rate %q, want %q", tt.name, rate.Name, tt.want)
+		}
+	}
+
+	if _, err := findShippingRate(ctx, pool, models.ShippingMethodPickup, nil, 1); err != ErrNoShippingRate {
+		t.Errorf("no pickup rate: err = %v, want %v", err, ErrNoShippingRate)
+	}
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// ErrInStock is returned when subscribing to a product that can be bought now.
+var ErrInStock = errors.New("product is in stock")
+
+// StockAlertRepository handles database operations for back-in-stock
+// subscriptions.
+type StockAlertRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewStockAlertRepository(db *pgxpool.Pool) *StockAlertRepository {
+	return &StockAlertRepository{db: db}
+}
+
+const stockSubscriptionQuery = `
+	SELECT s.subscription_id, s.product_id, p.product_name, s.size_type, s.channel, s.notified_at, s.created_at
+	FROM stock_subscriptions s
+	JOIN products p ON p.product_id = s.product_id`
+
+func scanStockSubscription(row pgx.Row) (*models.StockSubscription, error) {
+	var sub models.StockSubscription
+	if err := row.Scan(
+		&sub.ID, &sub.ProductID, &sub.ProductName, &sub.SizeType, &sub.Channel, &sub.NotifiedAt, &sub.CreatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan stock subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+// Subscribe records that the client wants to hear when the product, or the
+// given size, is back in stock. Subscribing again updates the channel and
+// re-arms a subscription that was already notified. Products that are in
+// stock give ErrInStock; unknown products and sizes give the errors of
+// checkProductOffered.
+func (r *StockAlertRepository) Subscribe(ctx context.Context, clientID, productID int64, sizeType *models.SizeType, channel models.StockAlertChannel) (*models.StockSubscription, error) {
+	if err := checkProductOffered(ctx, r.db, productID, sizeType); err != nil {
+		return nil, err
+	}
+
+	var stock int
+	err := r.db.QueryRow(ctx, `
+		SELECT stock.quantity
+		FROM (SELECT $1::int AS product_id, $2::size_type_enum AS size_type) s
+		JOIN products p ON p.product_id = s.product_id`+liveStockJoins("s"),
+		productID, sizeType).Scan(&stock)
+	if err != nil {
+		return nil, fmt.Errorf("check stock: %w", err)
+	}
+	if stock > 0 {
+		return nil, ErrInStock
+	}
+
+	var subscriptionID int64
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO stock_subscriptions (client_id, product_id, size_type, channel)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (client_id, product_id, (COALESCE(size_type::text, '')))
+		DO UPDATE SET channel = EXCLUDED.channel, notified_at = NULL
+		RETURNING subscription_id`,
+		clientID, productID, sizeType, channel).Scan(&subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("insert stock subscription: %w", err)
+	}
+
+	return scanStockSubscription(r.db.QueryRow(ctx, stockSubscriptionQuery+`
+		WHERE s.subscription_id = $1`, subscriptionID))
+}
+
+// ListByClient returns the client's subscriptions, newest first.
+func (r *StockAlertRepository) ListByClient(ctx context.Context, clientID int64) ([]models.StockSubscription, error) {
+	rows, err := r.db.Query(ctx, stockSubscriptionQuery+`
+		WHERE s.client_id = $1
+		ORDER BY s.created_at DESC, s.subscription_id DESC`, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("query stock subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []models.StockSubscription{}
+	for rows.Next() {
+		sub, err := scanStockSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, *sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return subs, nil
+}
+
+// Unsubscribe deletes one of the client's subscriptions.
+func (r *StockAlertRepository) Unsubscribe(ctx context.Context, clientID, subscriptionID int64) error {
+	tag, err := r.db.Exec(ctx, `
+		DELETE FROM stock_subscriptions WHERE client_id = $1 AND subscription_id = $2`,
+		clientID, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("delete stock subscription: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ClaimRestocked marks the not-yet-notified subscriptions of productID, and
+// of the bundles it is a component of, whose product or size can be bought
+// again as notified and returns them. Claiming
+// and marking happen in one statement, so concurrent restocks never claim the
+// same subscription twice.
+func (r *StockAlertRepository) ClaimRestocked(ctx context.Context, productID int64) ([]models.StockAlert, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE stock_subscriptions sub
+		SET notified_at = CURRENT_TIMESTAMP
+		FROM (
+			SELECT s.subscription_id, COALESCE(p.slug, '') AS slug, p.product_name, c.phone, c.email
+			FROM stock_subscriptions s
+			JOIN client c ON c.client_id = s.client_id
+			JOIN products p ON p.product_id = s.product_id`+liveStockJoins("s")+`
+			WHERE (s.product_id = $1 OR s.product_id IN (
+			          SELECT bundle_product_id FROM bundle_components WHERE component_product_id = $1))
+			  AND s.notified_at IS NULL
+			  AND COALESCE(p.is_active, false) AND `+publishedCond("p")+` AND COALESCE(c.is_active, true)
+			  AND stock.quantity > 0
+		) due
+		WHERE sub.subscription_id = due.subscription_id AND sub.notified_at IS NULL
+		RETURNING sub.subscription_id, sub.product_id, due.slug, due.product_name, sub.size_type, sub.channel, due.phone, due.email`,
+		productID)
+	if err != nil {
+		return nil, fmt.Errorf("claim stock alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []models.StockAlert
+	for rows.Next() {
+		var a models.StockAlert
+		if err := rows.Scan(&a.SubscriptionID, &a.ProductID, &a.Slug, &a.ProductName, &a.SizeType, &a.Channel, &a.Phone, &a.Email); err != nil {
+			return nil, fmt.Errorf("scan stock alert: %w", err)
+		}
+		alerts = append(alerts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return alerts, nil
+}
+
+// Release clears notified_at after an alert failed to send, so the next
+// restock tries again.
+func (r *StockAlertRepository) Release(ctx context.Context, subscriptionID int64) error {
+	if _, err := r.db.Exec(ctx, `
+		UPDATE stock_subscriptions SET notified_at = NULL WHERE subscription_id = $1`, subscriptionID); err != nil {
+		return fmt.Errorf("release stock alert: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// testPool returns a pool on a fresh schema of the database at
+// TEST_DATABASE_URL with the migrations applied, and drops the schema when the
+// test ends. Tests needing it are skipped when the variable is unset.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := conn.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		conn.Close(ctx)
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := conn.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("drop schema: %v", err)
+		}
+		conn.Close(context.Background())
+	})
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema + ", public"
+	cfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+	migrate, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	files, err := filepath.Glob("../../../db/migrations/[0-9]*.sql")
+	if err != nil || len(files) == 0 {
+		migrate.Close()
+		t.Fatalf("migrations: %v", err)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		sql, err := os.ReadFile(f)
+		if err != nil {
+			migrate.Close()
+			t.Fatalf("read %s: %v", f, err)
+		}
+		if _, err := migrate.Exec(ctx, string(sql)); err != nil {
+			migrate.Close()
+			t.Fatalf("apply %s: %v", filepath.Base(f), err)
+		}
+	}
+	migrate.Close()
+
+	// A new pool, so that no connection caches types from before the
+	// migrations.
+	cfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// createTestProduct creates a listed product and returns its ID.
+func createTestProduct(t *testing.T, repo *ProductRepository, sku string, productType models.ProductType, price float64, quantity int, sizes ...models.SizeType) int64 {
+	t.Helper()
+	name := "Product " + sku
+	id, err := repo.CreateProduct(context.Background(), ProductParams{
+		Name:           &name,
+		SKU:            &sku,
+		Type:           &productType,
+		Price:          &price,
+		Quantity:       &quantity,
+		AvailableSizes: sizes,
+	})
+	if err != nil {
+		t.Fatalf("CreateProduct(%s): %v", sku, err)
+	}
+	return id
+}
+
+// variantStock returns the stock of each of a product's sizes.
+func variantStock(t *testing.T, pool *pgxpool.Pool, productID int64) map[models.SizeType]int {
+	t.Helper()
+	rows, err := pool.Query(context.Background(),
+		`SELECT size_type, quantity FROM product_variants WHERE product_id = $1`, productID)
+	if err != nil {
+		t.Fatalf("query variants: %v", err)
+	}
+	defer rows.Close()
+	stock := make(map[models.SizeType]int)
+	for rows.Next() {
+		var size models.SizeType
+		var quantity int
+		if err := rows.Scan(&size, &quantity); err != nil {
+			t.Fatalf("scan variant: %v", err)
+		}
+		stock[size] = quantity
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows: %v", err)
+	}
+	return stock
+}
+
+// productStock returns products.quantity.
+func productStock(t *testing.T, pool *pgxpool.Pool, productID int64) int {
+	t.Helper()
+	var quantity int
+	err := pool.QueryRow(context.Background(),
+		`SELECT COALESCE(quantity, 0) FROM products WHERE product_id = $1`, productID).Scan(&quantity)
+	if err != nil {
+		t.Fatalf("query product: %v", err)
+	}
+	return quantity
+}
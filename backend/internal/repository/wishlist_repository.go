@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ryangel/ryangel-backend/internal/models"
+)
+
+// WishlistRepository handles database operations for client wishlists.
+type WishlistRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWishlistRepository(db *pgxpool.Pool) *WishlistRepository {
+	return &WishlistRepository{db: db}
+}
+
+// wishlistQuery selects wishlist items with live price and stock. The
+// thumbnail is picked the same way as in the cart.
+var wishlistQuery = `
+	SELECT w.wishlist_item_id, w.product_id, COALESCE(p.slug, ''), p.product_name, p.product_type,
+	       w.size_type, v.variant_id,
+	       COALESCE(v.price, p.price),
+	       CASE WHEN v.variant_id IS NOT NULL THEN v.compare_at_price ELSE p.compare_at_price END,
+	       stock.quantity,
+	       COALESCE(p.is_active, false) AND `+publishedCond("p")+` AND stock.quantity > 0,
+	       COALESCE(img.thumbnail_path, img.image_path, ''),
+	       w.added_at
+	FROM wishlist_items w
+	JOIN products p ON p.product_id = w.product_id` + liveStockJoins("w") + `
+	LEFT JOIN LATERAL (
+		SELECT thumbnail_path, image_path
+		FROM product_images pi
+		WHERE pi.product_id = w.product_id
+		ORDER BY
+			CASE
+				WHEN w.size_type IS NOT NULL AND pi.size_type = w.size_type THEN 0
+				WHEN pi.size_type IS NULL THEN 1
+				ELSE 2
+			END,
+			pi.is_primary DESC,
+			pi.sort_order ASC
+		LIMIT 1
+	) img ON true`
+
+func scanWishlistItem(row pgx.Row) (*models.WishlistItem, error) {
+	var item models.WishlistItem
+	if err := row.Scan(
+		&item.ID, &item.ProductID, &item.Slug, &item.ProductName, &item.ProductType,
+		&item.SizeType, &item.VariantID, &item.Price, &item.CompareAtPrice,
+		&item.StockQuantity, &item.InStock, &item.ThumbnailURL, &item.AddedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan wishlist item: %w", err)
+	}
+	return &item, nil
+}
+
+// List returns the client's wishlist, most recently added first.
+func (r *WishlistRepository) List(ctx context.Context, clientID int64) ([]models.WishlistItem, error) {
+	rows, err := r.db.Query(ctx, wishlistQuery+`
+		WHERE w.client_id = $1
+		ORDER BY w.added_at DESC, w.wishlist_item_id DESC`, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("query wishlist: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.WishlistItem{}
+	for rows.Next() {
+		item, err := scanWishlistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	if err := applyWishlistPrices(ctx, r.db, items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Get returns one of the client's wishlist items. Items of other clients give
+// ErrNotFound.
+func (r *WishlistRepository) Get(ctx context.Context, clientID, itemID int64) (*models.WishlistItem, error) {
+	item, err := scanWishlistItem(r.db.QueryRow(ctx, wishlistQuery+`
+		WHERE w.client_id = $1 AND w.wishlist_item_id = $2`, clientID, itemID))
+	if err != nil {
+		return nil, err
+	}
+	items := []models.WishlistItem{*item}
+	if err := applyWishlistPrices(ctx, r.db, items); err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// applyWishlistPrices clears compare-at prices the items did not recently
+// sell at, as on product responses.
+func applyWishlistPrices(ctx context.Context, db *pgxpool.Pool, items []models.WishlistItem) error {
+	productIDs := make([]int64, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	prices, err := getRecentPrices(ctx, db, productIDs)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		item := &items[i]
+		key := priceKey{productID: item.ProductID}
+		if item.VariantID != nil {
+			key.variantID = *item.VariantID
+		}
+		item.CompareAtPrice = prices[key].CompareAt(item.Price, item.CompareAtPrice)
+	}
+	return nil
+}
+
+// Add saves a product, optionally for one size, and returns the entry. Saving
+// the same product and size again returns the existing entry with created
+// false. Inactive or unknown products give ErrNotFound and sizes the product
+// is not offered in give ErrVariantUnavailable.
+func (r *WishlistRepository) Add(ctx context.Context, clientID, productID int64, sizeType *models.SizeType) (*models.WishlistItem, bool, error) {
+	if err := checkProductOffered(ctx, r.db, productID, sizeType); err != nil {
+		return nil, false, err
+	}
+
+	var itemID int64
+	created := true
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO wishlist_items (client_id, product_id, size_type)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (client_id, product_id, (COALESCE(size_type::text, ''))) DO NOTHING
+		RETURNING wishlist_item_id`, clientID, productID, sizeType).Scan(&itemID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		created = false
+		err = r.db.QueryRow(ctx, `
+			SELECT wishlist_item_id FROM wishlist_items
+			WHERE client_id = $1 AND product_id = $2 AND size_type IS NOT DISTINCT FROM $3::size_type_enum`,
+			clientID, productID, sizeType).Scan(&itemID)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("insert wishlist item: %w", err)
+	}
+
+	item, err := r.Get(ctx, clientID, itemID)
+	return item, created, err
+}
+
+// checkProductOffered confirms the product is listed (active and inside its
+// publishing window) and, when sizeType is set, offered in that size. It
+// returns ErrNotFound or ErrVariantUnavailable otherwise.
+func checkProductOffered(ctx context.Context, db *pgxpool.Pool, productID int64, sizeType *models.SizeType) error {
+	var offered bool
+	err := db.QueryRow(ctx, `
+		SELECT $2::size_type_enum IS NULL OR $2::size_type_enum = ANY(COALESCE(available_sizes, '{}'))
+		FROM products
+		WHERE product_id = $1 AND COALESCE(is_active, false) AND `+publishedCond("products"),
+		productID, sizeType).Scan(&offered)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("check product: %w", err)
+	}
+	if !offered {
+		return ErrVariantUnavailable
+	}
+	return nil
+}
+
+// Remove deletes one of the client's wishlist items.
+func (r *WishlistRepository) Remove(ctx context.Context, clientID, itemID int64) error {
+	tag, err := r.db.Exec(ctx, `
+		DELETE FROM wishlist_items WHERE client_id = $1 AND wishlist_item_id = $2`, clientID, itemID)
+	if err != nil {
+		return fmt.Errorf("delete wishlist item: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -413,6 +413,12 @@ func (s *Service) generateOTP() string {
 	return string(b)
 }
 
+// SendSMS sends a text message through Twilio, or only logs it when
+// SKIP_SMS_SENDING is set.
+func (s *Service) SendSMS(to, message string) error {
+	return s.sendSMS(to, message)
+}
+
 func (s *Service) sendSMS(to, message string) error {
 	if s.cfg.SkipSMSSending {
 		// In development mode, skip SMS sending and just log the message
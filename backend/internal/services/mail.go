@@ -0,0 +1,44 @@
+package services
+
+import (
+	"fmt"
+	"mime"
+	"net/mail"
+	"net/smtp"
+	"strings"
+
+	"github.com/ryangel/ryangel-backend/internal/config"
+)
+
+// sendEmail sends a plain-text email through the configured SMTP server. With
+// no SMTP_HOST the email is only logged, like SMS in development. Addresses
+// with line breaks are rejected so they cannot add headers.
+func sendEmail(cfg *config.Config, to, subject, body string) error {
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid recipient address %q", to)
+	}
+	if cfg.SMTPHost == "" {
+		fmt.Printf("[DEV MODE] Would send email to %s: %s\n%s\n", to, subject, body)
+		return nil
+	}
+
+	from, err := mail.ParseAddress(cfg.MailFrom)
+	if err != nil {
+		return fmt.Errorf("parse MAIL_FROM: %w", err)
+	}
+
+	var msg strings.Builder
+	msg.WriteString("From: " + from.String() + "\r\n")
+	msg.WriteString("To: " + (&mail.Address{Address: to}).String() + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	return smtp.SendMail(cfg.SMTPHost+":"+cfg.SMTPPort, auth, from.Address, []string{to}, []byte(msg.String()))
+}
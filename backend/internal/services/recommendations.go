@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ryangel/ryangel-backend/internal/repository"
+)
+
+// coPurchaseRefreshInterval is how often the co-purchase counts behind
+// related products are recomputed from order history.
+const coPurchaseRefreshInterval = 6 * time.Hour
+
+// RecommendationService keeps the data behind product recommendations fresh.
+type RecommendationService struct {
+	repo *repository.ProductRepository
+}
+
+func NewRecommendationService(db *pgxpool.Pool) *RecommendationService {
+	return &RecommendationService{repo: repository.NewProductRepository(db)}
+}
+
+// StartScheduler refreshes co-purchase counts at startup and then every
+// coPurchaseRefreshInterval until ctx is cancelled.
+func (s *RecommendationService) StartScheduler(ctx context.Context) {
+	go func() {
+		if err := s.repo.RefreshCoPurchases(ctx); err != nil {
+			fmt.Printf("Error refreshing product co-purchases at startup: %v\n", err)
+		}
+
+		ticker := time.NewTicker(coPurchaseRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.repo.RefreshCoPurchases(ctx); err != nil {
+					fmt.Printf("Error refreshing product co-purchases: %v\n", err)
+				}
+			}
+		}
+	}()
+}
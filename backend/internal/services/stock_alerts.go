@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ryangel/ryangel-backend/internal/config"
+	"github.com/ryangel/ryangel-backend/internal/models"
+	"github.com/ryangel/ryangel-backend/internal/repository"
+)
+
+// SMSSender sends text messages. The auth service's Twilio client is one.
+type SMSSender interface {
+	SendSMS(to, message string) error
+}
+
+// errNoContact means the client has no phone or email for the chosen channel.
+// Such alerts are not retried.
+var errNoContact = errors.New("client has no contact for channel")
+
+// StockAlertService tells subscribed clients when products are back in stock.
+type StockAlertService struct {
+	repo *repository.StockAlertRepository
+	sms  SMSSender
+	cfg  *config.Config
+}
+
+func NewStockAlertService(db *pgxpool.Pool, sms SMSSender, cfg *config.Config) *StockAlertService {
+	return &StockAlertService{repo: repository.NewStockAlertRepository(db), sms: sms, cfg: cfg}
+}
+
+// NotifyRestocked alerts the subscribers of productID, or of bundles it is
+// part of, whose product or size can be bought again. Each subscription is
+// alerted once; alerts that fail to send are released and retried on the
+// next restock. Errors are logged.
+func (s *StockAlertService) NotifyRestocked(ctx context.Context, productID int64) {
+	alerts, err := s.repo.ClaimRestocked(ctx, productID)
+	if err != nil {
+		fmt.Printf("Error claiming stock alerts for product %d: %v\n", productID, err)
+		return
+	}
+
+	for _, alert := range alerts {
+		err := s.send(alert)
+		if err == nil {
+			continue
+		}
+		fmt.Printf("Error sending stock alert %d: %v\n", alert.SubscriptionID, err)
+		if errors.Is(err, errNoContact) {
+			continue
+		}
+		if err := s.repo.Release(ctx, alert.SubscriptionID); err != nil {
+			fmt.Printf("Error releasing stock alert %d: %v\n", alert.SubscriptionID, err)
+		}
+	}
+}
+
+func (s *StockAlertService) send(alert models.StockAlert) error {
+	name := alert.ProductName
+	if alert.SizeType != nil {
+		name += " (" + string(*alert.SizeType) + ")"
+	}
+	link := strings.TrimRight(s.cfg.SiteBaseURL, "/") + models.ProductPath(alert.ProductID, alert.Slug)
+
+	if alert.Channel == models.StockAlertChannelEmail {
+		if alert.Email == nil || *alert.Email == "" {
+			return errNoContact
+		}
+		return sendEmail(s.cfg, *alert.Email, "RyAngel 補貨通知",
+			fmt.Sprintf("你訂閱的「%s」已經補貨！\n\n%s\n", name, link))
+	}
+
+	if alert.Phone == nil || *alert.Phone == "" {
+		return errNoContact
+	}
+	if s.sms == nil {
+		return errors.New("SMS sending is not configured")
+	}
+	return s.sms.SendSMS(*alert.Phone, fmt.Sprintf("RyAngel：你訂閱的「%s」已經補貨！%s", name, link))
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LocalStorage keeps objects on the local filesystem beneath a root directory.
+type LocalStorage struct {
+	root    string
+	baseURL string
+	private bool
+}
+
+// NewLocalStorage creates a filesystem-backed store. Public stores render
+// URLs beneath baseURL (served by Nginx); private stores restrict file
+// permissions to the server user and render no URLs.
+func NewLocalStorage(root, baseURL string, private bool) *LocalStorage {
+	return &LocalStorage{root: root, baseURL: baseURL, private: private}
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	fullPath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	dirMode, fileMode := os.FileMode(0755), os.FileMode(0644)
+	if s.private {
+		dirMode, fileMode = 0700, 0600
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), dirMode); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileMode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		os.Remove(fullPath)
+		return err
+	}
+	return out.Close()
+}
+
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	fullPath, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(fullPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	fullPath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fullPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	if s.private || s.baseURL == "" {
+		return ""
+	}
+	return joinURL(s.baseURL, key)
+}
+
+func (s *LocalStorage) KeyFromURL(url string) (string, bool) {
+	if s.private {
+		return "", false
+	}
+	return trimBase(s.baseURL, url)
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
@@ -0,0 +1,284 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Options configures an S3-compatible object store (AWS S3, MinIO, R2, ...).
+type S3Options struct {
+	Endpoint  string // e.g. https://s3.ap-east-1.amazonaws.com or http://localhost:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PublicURL overrides the base used for public object URLs, e.g. a CDN.
+	PublicURL string
+	// PathStyle addresses objects as endpoint/bucket/key, as MinIO expects.
+	PathStyle bool
+	Private   bool
+}
+
+// S3Storage stores objects in an S3-compatible bucket using SigV4-signed requests.
+type S3Storage struct {
+	opts   S3Options
+	client *http.Client
+}
+
+func NewS3Storage(opts S3Options) *S3Storage {
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	return &S3Storage{
+		opts:   opts,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	// Uploads are small images; buffering lets us sign the payload hash.
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, body, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s.responseError("put", key, resp)
+	}
+	return nil
+}
+
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, nil, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, s.responseError("get", key, resp)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, nil, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// S3 answers 204 for deletes, including deletes of missing keys.
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s.responseError("delete", key, resp)
+	}
+	return nil
+}
+
+func (s *S3Storage) URL(key string) string {
+	if s.opts.Private {
+		return ""
+	}
+	if s.opts.PublicURL != "" {
+		return joinURL(s.opts.PublicURL, key)
+	}
+	u, err := s.objectURL(key)
+	if err != nil {
+		return ""
+	}
+	return u.String()
+}
+
+func (s *S3Storage) KeyFromURL(rawURL string) (string, bool) {
+	if s.opts.Private {
+		return "", false
+	}
+	if s.opts.PublicURL != "" {
+		return trimBase(s.opts.PublicURL, rawURL)
+	}
+	base, err := s.objectURL("")
+	if err != nil {
+		return "", false
+	}
+	return trimBase(base.String(), rawURL)
+}
+
+func (s *S3Storage) objectURL(key string) (*url.URL, error) {
+	u, err := url.Parse(s.opts.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse S3 endpoint: %w", err)
+	}
+
+	escapedKey := uriEncodePath(key)
+	if s.opts.PathStyle {
+		u.Path = "/" + s.opts.Bucket + "/" + key
+		u.RawPath = "/" + uriEncode(s.opts.Bucket) + "/" + escapedKey
+	} else {
+		u.Host = s.opts.Bucket + "." + u.Host
+		u.Path = "/" + key
+		u.RawPath = "/" + escapedKey
+	}
+	return u, nil
+}
+
+func (s *S3Storage) newRequest(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.objectURL(cleaned)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	return http.NewRequestWithContext(ctx, method, u.String(), reader)
+}
+
+// sign applies an AWS Signature Version 4 Authorization header.
+func (s *S3Storage) sign(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		headers["content-type"] = ct
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := s.scope(date)
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKey, scope, signedHeaders, s.signature(date, amzDate, canonicalRequest),
+	))
+}
+
+// scope is the SigV4 credential scope for a day.
+func (s *S3Storage) scope(date string) string {
+	return date + "/" + s.opts.Region + "/s3/aws4_request"
+}
+
+// signature signs a canonical request with a key derived from the secret.
+func (s *S3Storage) signature(date, amzDate, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		s.scope(date),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.opts.SecretKey), date)
+	signingKey = hmacSHA256(signingKey, s.opts.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+}
+
+func (s *S3Storage) responseError(op, key string, resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("s3 %s %s: status %d: %s", op, key, resp.StatusCode, strings.TrimSpace(string(msg)))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncodePath encodes each segment of a key as SigV4 requires.
+func uriEncodePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		segments[i] = uriEncode(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode escapes everything except RFC 3986 unreserved characters.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
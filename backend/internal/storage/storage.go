@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/ryangel/ryangel-backend/internal/config"
+)
+
+// ErrNotFound is returned when an object does not exist in storage.
+var ErrNotFound = errors.New("object not found")
+
+// Storage persists uploaded media under slash-separated keys such as
+// "products/abc.jpg". Implementations must be safe for concurrent use.
+type Storage interface {
+	// Put writes the object, replacing any existing object with the same key.
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Open returns a reader for the object or ErrNotFound.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL renders the public URL for a key, or "" for private storage.
+	URL(key string) string
+	// KeyFromURL reverses URL for objects held by this storage.
+	KeyFromURL(url string) (string, bool)
+}
+
+// Visibility selects between the public media store and the private store
+// used for sensitive uploads such as payment proofs.
+type Visibility int
+
+const (
+	Public Visibility = iota
+	Private
+)
+
+// New builds the storage backend selected by STORAGE_DRIVER.
+func New(cfg *config.Config, visibility Visibility) (Storage, error) {
+	switch strings.ToLower(cfg.StorageDriver) {
+	case "", "local":
+		if visibility == Private {
+			return NewLocalStorage(cfg.PrivateStoragePath, "", true), nil
+		}
+		return NewLocalStorage(cfg.MediaStoragePath, cfg.MediaBaseURL, false), nil
+	case "s3":
+		opts := S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKeyID,
+			SecretKey: cfg.S3SecretAccessKey,
+			PublicURL: cfg.S3PublicURL,
+			PathStyle: cfg.S3UsePathStyle,
+		}
+		if visibility == Private {
+			opts.Bucket = cfg.S3PrivateBucket
+			opts.PublicURL = ""
+			opts.Private = true
+		}
+		if opts.Endpoint == "" || opts.Bucket == "" {
+			return nil, fmt.Errorf("S3_ENDPOINT and bucket are required for the s3 storage driver")
+		}
+		return NewS3Storage(opts), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+	}
+}
+
+// RandomKey returns an unguessable key under prefix with the given extension.
+func RandomKey(prefix, ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return path.Join(prefix, hex.EncodeToString(b)+ext), nil
+}
+
+// cleanKey normalises a key and prevents it from escaping the storage root.
+func cleanKey(key string) (string, error) {
+	cleaned := strings.TrimPrefix(path.Clean("/"+key), "/")
+	if cleaned == "" {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return cleaned, nil
+}
+
+// joinURL appends a key to a base URL without doubling slashes.
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + key
+}
+
+// trimBase strips base from url and returns the remaining key.
+func trimBase(base, url string) (string, bool) {
+	prefix := strings.TrimRight(base, "/") + "/"
+	if base == "" || !strings.HasPrefix(url, prefix) {
+		return "", false
+	}
+	key, err := cleanKey(strings.TrimPrefix(url, prefix))
+	if err != nil {
+		return "", false
+	}
+	return key, true
+}
@@ -0,0 +1,7 @@
+-- Payment proofs moved out of the public media root into private storage.
+-- proof_path now holds a key relative to PRIVATE_STORAGE_PATH.
+-- Move existing files before running, e.g.:
+--   mv /var/www/media/uploads/proofs/* $PRIVATE_STORAGE_PATH/proofs/
+UPDATE payment_proofs
+SET proof_path = 'proofs/' || substring(proof_path FROM length('/media/uploads/proofs/') + 1)
+WHERE proof_path LIKE '/media/uploads/proofs/%';
@@ -0,0 +1,34 @@
+-- Per-size pricing and stock. A product with variants sells only the sizes
+-- listed here; products without variants (e.g. bags) keep using
+-- products.price and products.quantity.
+CREATE TABLE product_variants (
+    variant_id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    size_type size_type_enum NOT NULL,
+    sku_suffix VARCHAR(50) NOT NULL,
+    price DECIMAL(10,2) NOT NULL,
+    compare_at_price DECIMAL(10,2),
+    quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, size_type),
+    UNIQUE (product_id, sku_suffix)
+);
+
+CREATE TRIGGER update_product_variants_updated_at BEFORE UPDATE ON product_variants FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Order items keep a reference to the variant they were sold from so that
+-- cancelling an order can return the stock.
+ALTER TABLE order_items ADD COLUMN variant_id INT REFERENCES product_variants(variant_id) ON DELETE SET NULL;
+
+-- Backfill one variant per available size at the current product price.
+-- Stock used to be shared across sizes, so it is split across them in
+-- available_sizes order, with the remainder going to the first sizes.
+INSERT INTO product_variants (product_id, size_type, sku_suffix, price, compare_at_price, quantity)
+SELECT p.product_id, s.size_type, '-' || s.size_type::text, p.price, p.compare_at_price,
+       GREATEST(COALESCE(p.quantity, 0), 0) / cardinality(p.available_sizes)
+       + CASE WHEN s.n <= GREATEST(COALESCE(p.quantity, 0), 0) % cardinality(p.available_sizes) THEN 1 ELSE 0 END
+FROM products p
+CROSS JOIN LATERAL unnest(p.available_sizes) WITH ORDINALITY AS s(size_type, n)
+ON CONFLICT (product_id, size_type) DO NOTHING;
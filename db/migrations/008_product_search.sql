@@ -0,0 +1,23 @@
+-- Full-text product search. search_vector ranks word matches (the 'simple'
+-- configuration keeps brand names and romanisations unstemmed); search_text
+-- backs a trigram fallback for Chinese phrases, which the text parser cannot
+-- split into words.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE products ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(product_name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(sku, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(hashtag, '') || ' ' || coalesce(tags::text, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(product_description, '')), 'C')
+) STORED;
+
+ALTER TABLE products ADD COLUMN search_text TEXT GENERATED ALWAYS AS (
+    coalesce(product_name, '') || ' ' ||
+    coalesce(sku, '') || ' ' ||
+    coalesce(hashtag, '') || ' ' ||
+    coalesce(tags::text, '') || ' ' ||
+    coalesce(product_description, '')
+) STORED;
+
+CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector);
+CREATE INDEX idx_products_search_text_trgm ON products USING GIN (search_text gin_trgm_ops);
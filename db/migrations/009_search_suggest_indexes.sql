@@ -0,0 +1,6 @@
+-- Prefix indexes for GET /products/suggest. Lookups compare lower-cased
+-- values as ranges under the "C" collation so the indexes stay usable with
+-- prepared statements.
+CREATE INDEX idx_products_name_prefix ON products ((lower(product_name) COLLATE "C")) WHERE is_active;
+CREATE INDEX idx_products_hashtag_prefix ON products ((lower(ltrim(hashtag, '#')) COLLATE "C")) WHERE is_active AND hashtag IS NOT NULL;
+CREATE INDEX idx_categories_name_prefix ON categories ((lower(category_name) COLLATE "C"));
@@ -0,0 +1,13 @@
+-- URL slugs for products. Slugs are generated by the API (pinyin for Chinese
+-- names), so existing rows are filled in by the server on startup, or by
+-- `go run ./cmd/products backfill-slugs`.
+ALTER TABLE products ADD COLUMN slug VARCHAR(100);
+CREATE UNIQUE INDEX idx_products_slug ON products (slug);
+
+-- Previous slugs keep resolving to their product after a rename.
+CREATE TABLE product_slug_redirects (
+    old_slug VARCHAR(100) PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_product_slug_redirects_product ON product_slug_redirects (product_id);
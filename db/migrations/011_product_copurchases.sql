@@ -0,0 +1,18 @@
+-- How many orders contain each pair of products, for "frequently bought
+-- together". Cancelled and refunded orders and free BXGY items are ignored.
+-- The API refreshes it periodically with REFRESH MATERIALIZED VIEW CONCURRENTLY.
+-- 017 recreates it to leave out bundle component lines.
+CREATE MATERIALIZED VIEW product_copurchases AS
+SELECT a.product_id,
+       b.product_id AS related_product_id,
+       COUNT(DISTINCT a.order_id)::int AS order_count
+FROM order_items a
+JOIN order_items b ON b.order_id = a.order_id AND b.product_id <> a.product_id
+JOIN orders o ON o.order_id = a.order_id
+WHERE o.order_status NOT IN ('cancelled', 'refunded')
+  AND NOT COALESCE(a.is_free_item, false)
+  AND NOT COALESCE(b.is_free_item, false)
+GROUP BY a.product_id, b.product_id;
+
+-- Required by REFRESH ... CONCURRENTLY and used for lookups by product.
+CREATE UNIQUE INDEX idx_product_copurchases_pair ON product_copurchases (product_id, related_product_id);
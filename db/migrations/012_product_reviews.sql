@@ -0,0 +1,25 @@
+-- Product reviews from verified buyers. Reviews start pending and only
+-- approved ones are shown and counted in a product's rating.
+CREATE TYPE review_status_enum AS ENUM ('pending', 'approved', 'rejected');
+
+CREATE TABLE product_reviews (
+    review_id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    client_id INT NOT NULL REFERENCES client(client_id) ON DELETE CASCADE,
+    -- The delivered order that made the client a verified buyer.
+    order_id INT REFERENCES orders(order_id) ON DELETE SET NULL,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    body TEXT,
+    photo_path VARCHAR(255),
+    status review_status_enum NOT NULL DEFAULT 'pending',
+    moderated_by INT REFERENCES admin(admin_id),
+    moderated_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, client_id)
+);
+
+CREATE INDEX idx_product_reviews_product_status ON product_reviews (product_id, status, created_at DESC);
+CREATE INDEX idx_product_reviews_status ON product_reviews (status, created_at);
+
+CREATE TRIGGER update_product_reviews_updated_at BEFORE UPDATE ON product_reviews FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,11 @@
+-- Products a client saved for later, optionally for a specific size.
+CREATE TABLE wishlist_items (
+    wishlist_item_id SERIAL PRIMARY KEY,
+    client_id INT NOT NULL REFERENCES client(client_id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    size_type size_type_enum,
+    added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One entry per product and size; a NULL size counts as its own value.
+CREATE UNIQUE INDEX idx_wishlist_items_unique ON wishlist_items (client_id, product_id, (COALESCE(size_type::text, '')));
@@ -0,0 +1,14 @@
+-- Back-in-stock alerts. A subscription is notified once: notified_at is set
+-- when the alert goes out and cleared again if the client re-subscribes.
+CREATE TABLE stock_subscriptions (
+    subscription_id SERIAL PRIMARY KEY,
+    client_id INT NOT NULL REFERENCES client(client_id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    size_type size_type_enum,
+    channel VARCHAR(10) NOT NULL DEFAULT 'sms' CHECK (channel IN ('sms', 'email')),
+    notified_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_stock_subscriptions_unique ON stock_subscriptions (client_id, product_id, (COALESCE(size_type::text, '')));
+CREATE INDEX idx_stock_subscriptions_pending ON stock_subscriptions (product_id) WHERE notified_at IS NULL;
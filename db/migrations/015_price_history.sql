@@ -0,0 +1,53 @@
+-- Every price a product or variant has been sold at. Rows are written by
+-- triggers so admin edits, catalogue imports and manual SQL are all
+-- recorded. A compare-at price is only shown while the item really sold at
+-- that price (or higher) within the last 30 days.
+CREATE TABLE price_history (
+    price_history_id BIGSERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    -- NULL for the product's own price.
+    variant_id INT REFERENCES product_variants(variant_id) ON DELETE CASCADE,
+    price DECIMAL(10,2) NOT NULL,
+    compare_at_price DECIMAL(10,2),
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_price_history_product ON price_history (product_id, variant_id, changed_at);
+
+CREATE OR REPLACE FUNCTION record_product_price()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' OR NEW.price IS DISTINCT FROM OLD.price
+       OR NEW.compare_at_price IS DISTINCT FROM OLD.compare_at_price THEN
+        INSERT INTO price_history (product_id, variant_id, price, compare_at_price)
+        VALUES (NEW.product_id, NULL, NEW.price, NEW.compare_at_price);
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE FUNCTION record_variant_price()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' OR NEW.price IS DISTINCT FROM OLD.price
+       OR NEW.compare_at_price IS DISTINCT FROM OLD.compare_at_price THEN
+        INSERT INTO price_history (product_id, variant_id, price, compare_at_price)
+        VALUES (NEW.product_id, NEW.variant_id, NEW.price, NEW.compare_at_price);
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER record_products_price AFTER INSERT OR UPDATE ON products FOR EACH ROW EXECUTE FUNCTION record_product_price();
+CREATE TRIGGER record_product_variants_price AFTER INSERT OR UPDATE ON product_variants FOR EACH ROW EXECUTE FUNCTION record_variant_price();
+
+-- Earlier prices are unknown, so the current ones are taken to have applied
+-- since the row was created. Compare-at prices set before this migration are
+-- hidden until the item has sold at them.
+INSERT INTO price_history (product_id, variant_id, price, compare_at_price, changed_at)
+SELECT product_id, NULL, price, compare_at_price, COALESCE(created_at, CURRENT_TIMESTAMP)
+FROM products;
+
+INSERT INTO price_history (product_id, variant_id, price, compare_at_price, changed_at)
+SELECT product_id, variant_id, price, compare_at_price, COALESCE(created_at, CURRENT_TIMESTAMP)
+FROM product_variants;
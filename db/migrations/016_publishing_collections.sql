@@ -0,0 +1,39 @@
+-- Scheduled publishing. Products and categories are visible while active and
+-- inside their window; NULL means no bound on that side.
+ALTER TABLE products
+    ADD COLUMN publish_at TIMESTAMPTZ,
+    ADD COLUMN unpublish_at TIMESTAMPTZ,
+    ADD CONSTRAINT products_publish_window CHECK (unpublish_at IS NULL OR publish_at IS NULL OR unpublish_at > publish_at);
+
+ALTER TABLE categories
+    ADD COLUMN publish_at TIMESTAMPTZ,
+    ADD COLUMN unpublish_at TIMESTAMPTZ,
+    ADD CONSTRAINT categories_publish_window CHECK (unpublish_at IS NULL OR publish_at IS NULL OR unpublish_at > publish_at);
+
+-- Named, hand-curated collections (e.g. "2026 Year of the Horse") with a
+-- landing page. sort_order orders the collections; position orders the
+-- products within one.
+CREATE TABLE collections (
+    collection_id SERIAL PRIMARY KEY,
+    collection_name VARCHAR(255) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    collection_description TEXT,
+    sort_order INT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    publish_at TIMESTAMPTZ,
+    unpublish_at TIMESTAMPTZ,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT collections_publish_window CHECK (unpublish_at IS NULL OR publish_at IS NULL OR unpublish_at > publish_at)
+);
+
+CREATE TABLE collection_products (
+    collection_id INT NOT NULL REFERENCES collections(collection_id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    position INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (collection_id, product_id)
+);
+
+CREATE INDEX idx_collection_products_product ON collection_products (product_id);
+
+CREATE TRIGGER update_collections_updated_at BEFORE UPDATE ON collections FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,46 @@
+-- Bundles (faiachun sets, bag + faiachun gift packs) are products sold at
+-- their own price and made up of other products. They hold no stock of their
+-- own: buying one takes stock from the components.
+ALTER TYPE product_type_enum ADD VALUE IF NOT EXISTS 'bundle';
+
+-- size_type picks the component's variant and is NULL for products not sold
+-- per size. Components cannot themselves be bundles (enforced by the API).
+CREATE TABLE bundle_components (
+    bundle_component_id SERIAL PRIMARY KEY,
+    bundle_product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    component_product_id INT NOT NULL REFERENCES products(product_id),
+    size_type size_type_enum,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    position INT NOT NULL DEFAULT 0,
+    CONSTRAINT bundle_components_not_self CHECK (bundle_product_id <> component_product_id)
+);
+
+CREATE INDEX idx_bundle_components_bundle ON bundle_components(bundle_product_id, position);
+CREATE INDEX idx_bundle_components_component ON bundle_components(component_product_id);
+
+-- Bundle order lines are followed by one zero-priced line per component for
+-- fulfilment; those point back at the bundle line and carry the variant whose
+-- stock was taken, so cancellation restocks them like any other line.
+ALTER TABLE order_items
+    ADD COLUMN bundle_item_id INT REFERENCES order_items(order_item_id) ON DELETE CASCADE;
+
+CREATE INDEX idx_order_items_bundle_item ON order_items(bundle_item_id);
+
+-- Component lines are fulfilment detail, not purchases: co-purchases count
+-- the bundle line only.
+DROP MATERIALIZED VIEW product_copurchases;
+CREATE MATERIALIZED VIEW product_copurchases AS
+SELECT a.product_id,
+       b.product_id AS related_product_id,
+       COUNT(DISTINCT a.order_id)::int AS order_count
+FROM order_items a
+JOIN order_items b ON b.order_id = a.order_id AND b.product_id <> a.product_id
+JOIN orders o ON o.order_id = a.order_id
+WHERE o.order_status NOT IN ('cancelled', 'refunded')
+  AND NOT COALESCE(a.is_free_item, false)
+  AND NOT COALESCE(b.is_free_item, false)
+  AND a.bundle_item_id IS NULL
+  AND b.bundle_item_id IS NULL
+GROUP BY a.product_id, b.product_id;
+
+CREATE UNIQUE INDEX idx_product_copurchases_pair ON product_copurchases (product_id, related_product_id);
@@ -0,0 +1,30 @@
+-- Personalisation offered per product, e.g. custom wording (text, limited to
+-- max_length characters) and a font (choice). surcharge is added to the unit
+-- price when the option is filled in.
+CREATE TYPE customisation_type_enum AS ENUM ('text', 'choice');
+
+CREATE TABLE product_customisation_options (
+    option_id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    option_key VARCHAR(50) NOT NULL,
+    label VARCHAR(100) NOT NULL,
+    option_type customisation_type_enum NOT NULL,
+    max_length INT CHECK (max_length > 0),
+    choices TEXT[] NOT NULL DEFAULT '{}',
+    is_required BOOLEAN NOT NULL DEFAULT FALSE,
+    surcharge DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (surcharge >= 0),
+    position INT NOT NULL DEFAULT 0,
+    UNIQUE (product_id, option_key),
+    CONSTRAINT customisation_text_length CHECK (option_type <> 'text' OR max_length IS NOT NULL),
+    CONSTRAINT customisation_choices CHECK (option_type <> 'choice' OR cardinality(choices) > 0)
+);
+
+-- Chosen values keyed by option_key. The same product and size with
+-- different wording are separate cart lines.
+ALTER TABLE cart_items ADD COLUMN customisations JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE cart_items DROP CONSTRAINT IF EXISTS cart_items_cart_id_product_id_size_type_key;
+CREATE UNIQUE INDEX cart_items_line_key ON cart_items (cart_id, product_id, size_type, customisations);
+
+-- Snapshot of the chosen options with their labels and surcharges, which are
+-- included in unit_price.
+ALTER TABLE order_items ADD COLUMN customisations JSONB NOT NULL DEFAULT '[]';
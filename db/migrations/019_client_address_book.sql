@@ -0,0 +1,10 @@
+-- Address book: a contact phone per address, as the API documents, and
+-- archiving. Orders must keep either an address or a pickup store, so an
+-- address used by past orders is archived (hidden from the client) instead of
+-- deleted.
+ALTER TABLE client_address
+    ADD COLUMN phone VARCHAR(30),
+    ADD COLUMN archived_at TIMESTAMPTZ;
+
+CREATE INDEX idx_client_address_client ON client_address (client_id) WHERE archived_at IS NULL;
+CREATE INDEX idx_orders_shipping_address ON orders (shipping_address_id) WHERE shipping_address_id IS NOT NULL;
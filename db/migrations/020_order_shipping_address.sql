@@ -0,0 +1,3 @@
+-- Home delivery orders keep a copy of the address they ship to, since the
+-- client can edit or remove it from their address book afterwards.
+ALTER TABLE orders ADD COLUMN shipping_address JSONB;
@@ -0,0 +1,29 @@
+-- Shipping price rules. For a checkout, the active rate of the chosen method
+-- whose weight range holds the cart weight (products.weight in kg times
+-- quantity) is used, preferring a rate for the delivery address's region and
+-- then higher priority. Pickup has no region, so only region-less rates apply.
+CREATE TABLE shipping_rates (
+    rate_id SERIAL PRIMARY KEY,
+    rate_name VARCHAR(100) NOT NULL,
+    shipping_method VARCHAR(20) NOT NULL CHECK (shipping_method IN ('ebuy_pickup', 'home_delivery')),
+    region VARCHAR(100),
+    min_weight DECIMAL(10,3) NOT NULL DEFAULT 0 CHECK (min_weight >= 0),
+    max_weight DECIMAL(10,3),
+    base_fee DECIMAL(10,2) NOT NULL CHECK (base_fee >= 0),
+    fee_per_kg DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (fee_per_kg >= 0),
+    free_over DECIMAL(10,2) CHECK (free_over >= 0),
+    priority INT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT shipping_rates_weight_range CHECK (max_weight IS NULL OR max_weight > min_weight)
+);
+
+CREATE INDEX idx_shipping_rates_method ON shipping_rates (shipping_method) WHERE is_active;
+
+CREATE TRIGGER update_shipping_rates_updated_at BEFORE UPDATE ON shipping_rates FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- The flat fees charged until now.
+INSERT INTO shipping_rates (rate_name, shipping_method, base_fee) VALUES
+    ('Ebuy pickup', 'ebuy_pickup', 5.00),
+    ('Home delivery', 'home_delivery', 30.00);
@@ -19,6 +19,7 @@ import {
 import { cn } from '@/lib/utils';
 import { useIsMobile } from '@/hooks/use-mobile';
 import { useUser } from '@/hooks/useUser';
+import { useShippingQuote } from '@/hooks/useShippingQuote';
 
 const CartDrawer = () => {
   const [isOpen, setIsOpen] = useState(false);
@@ -35,6 +36,8 @@ const CartDrawer = () => {
     enabled: !!cartId, // Always fetch when cartId exists, not just when drawer is open
   });
 
+  const { quote: shippingQuote } = useShippingQuote();
+
   const cartItemCount = cart?.items?.reduce((total: number, item: CartItem) => total + item.quantity, 0) || 0;
 
   const updateQuantityMutation = useMutation({
@@ -179,7 +182,9 @@ const CartDrawer = () => {
             <div className="flex justify-between text-sm">
               <span>運費:</span>
               <div className="flex flex-col items-end">
-                 {cart.shipping_fee !== cart.discounted_shipping_fee ? (
+                 {!cart.shipping_available || cart.shipping_fee === null || cart.discounted_shipping_fee === null ? (
+                    <span className="text-red-500">暫不提供運送</span>
+                 ) : cart.shipping_fee !== cart.discounted_shipping_fee ? (
                   <>
                      <span className="line-through text-gray-400 text-xs">MOP$ {cart.shipping_fee.toFixed(2)}</span>
                      <span className="text-gray-800 font-medium">{`MOP$ ${cart.discounted_shipping_fee.toFixed(2)}`}</span>
@@ -189,11 +194,16 @@ const CartDrawer = () => {
                  )}
               </div>
             </div>
+            {shippingQuote?.amount_to_free_shipping != null && shippingQuote.amount_to_free_shipping > 0 && (
+              <div className="text-xs text-gray-500 text-right">
+                再買 MOP$ {shippingQuote.amount_to_free_shipping.toFixed(2)} 即可免運費
+              </div>
+            )}
             <div className="flex justify-between font-semibold text-lg border-t pt-2">
               <span>Total:</span>
               <span>MOP$ {cart.total.toFixed(2)}</span>
             </div>
-            <Button className="w-full" size="lg" onClick={handleCheckout}>
+            <Button className="w-full" size="lg" onClick={handleCheckout} disabled={!cart.shipping_available}>
               立刻結帳
             </Button>
           </div>
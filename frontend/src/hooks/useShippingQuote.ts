@@ -0,0 +1,22 @@
+import { useQuery } from '@tanstack/react-query';
+import { useSelector } from 'react-redux';
+import { callAPI } from '@/lib/api';
+import type { RootState } from '@/store';
+import type { ShippingMethod, ShippingQuote } from '@/lib/types';
+
+// Quotes shipping for the current cart. The query key sits under ['cart'] so
+// that cart changes refresh it. `unavailable` is set when no shipping rate
+// covers the cart (422 SHIPPING_UNAVAILABLE).
+export const useShippingQuote = (shippingMethod: ShippingMethod = 'ebuy_pickup') => {
+    const cartId = useSelector((state: RootState) => state.cart.cartId);
+
+    const query = useQuery<ShippingQuote>({
+        queryKey: ['cart', cartId, 'shippingQuote', shippingMethod],
+        queryFn: () => callAPI('getShippingQuote', { shipping_method: shippingMethod }),
+        enabled: !!cartId,
+        retry: false,
+    });
+
+    const unavailable = (query.error as any)?.response?.data?.error?.code === 'SHIPPING_UNAVAILABLE';
+    return { ...query, quote: query.data, unavailable };
+};
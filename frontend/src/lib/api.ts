@@ -8,6 +8,10 @@ import { toast } from 'sonner';
 
 const API_BASE = (import.meta.env.VITE_API_ROOT || 'https://ryangel.com/api');
 
+// Resolves a root-relative URL returned by the API (e.g. a signed payment
+// proof link) against the API's origin.
+export const apiURL = (path: string) => new URL(path, API_BASE).toString();
+
 interface ApiEndpoint {
   method: 'GET' | 'POST' | 'PUT' | 'DELETE' | 'PATCH';
   path: string;
@@ -61,6 +65,10 @@ export const api: Record<string, ApiEndpoint> = {
     method: 'GET',
     path: '/products/:productId'
   },
+  getProductBySlug: {
+    method: 'GET',
+    path: '/products/by-slug/:slug'
+  },
   addToCart: {
     method: 'POST',
     path: '/cart/items',
@@ -83,6 +91,12 @@ export const api: Record<string, ApiEndpoint> = {
     path: '/cart/items/:cartItemId',
     requiresAuth: true
   },
+  getShippingQuote: {
+    method: 'GET',
+    path: '/shipping/quote',
+    requiresCartId: true,
+    requiresAuth: true
+  },
   getEbuyStores: {
     method: 'GET',
     path: '/ebuystores'
@@ -2,6 +2,7 @@ export type SizeType = 'v-rect' | 'square' | 'fat-v-rect' | 'big-square';
 
 export interface Product {
   product_id: number;
+  slug: string;
   product_name: string;
   product_description: string;
   product_type: string;
@@ -9,7 +10,7 @@ export interface Product {
   sku: string;
   price: number;
   compare_at_price: number | null;
-  cost_price: number | null;
+  cost_price?: number | null; // admin responses only
   quantity: number;
   weight: number | null;
   dimensions: string | null;
@@ -19,7 +20,7 @@ export interface Product {
   seo_title: string | null;
   seo_description: string | null;
   tags: string | null;
-  created_by: number | null;
+  created_by?: number | null; // admin responses only
   created_at: string;
   updated_at: string;
   images: ProductImage[];
@@ -57,15 +58,34 @@ export interface CartItem {
   thumbnail_url: string;
 }
 
+export type ShippingMethod = 'ebuy_pickup' | 'home_delivery';
+
 export interface Cart {
   items: CartItem[];
+  shipping_method: ShippingMethod;
+  // False when no shipping rate covers the cart; the shipping fees are then
+  // null and total leaves shipping out.
+  shipping_available: boolean;
   subtotal: number;
   discounted_subtotal: number;
-  shipping_fee: number;
-  discounted_shipping_fee: number;
+  shipping_fee: number | null;
+  discounted_shipping_fee: number | null;
   discount: number;
   total: number;
 }
+
+export interface ShippingQuote {
+  shipping_method: ShippingMethod;
+  rate_id: number;
+  rate_name: string;
+  weight: number;
+  subtotal: number;
+  discounted_subtotal: number;
+  shipping_fee: number;
+  discounted_shipping_fee: number;
+  free_over: number | null;
+  amount_to_free_shipping: number | null;
+}
 export interface Order {
   order_id: number;
   order_number: string;
@@ -203,10 +203,14 @@ export default function Checkout() {
                                         </div>
                                         <div className="flex justify-between items-start text-sm">
                                             <span className="text-gray-600">運費</span>
-                                            <div className='flex flex-col text-right'>
-                                                <span>MOP$ {cart.shipping_fee}</span>
-                                                <span className={cn('text-green-600', {'hidden': cart.discounted_shipping_fee >= cart.shipping_fee})}>-MOP$ {cart.shipping_fee - cart.discounted_shipping_fee}</span>
-                                            </div>
+                                            {cart.shipping_available && cart.shipping_fee !== null && cart.discounted_shipping_fee !== null ? (
+                                                <div className='flex flex-col text-right'>
+                                                    <span>MOP$ {cart.shipping_fee}</span>
+                                                    <span className={cn('text-green-600', {'hidden': cart.discounted_shipping_fee >= cart.shipping_fee})}>-MOP$ {cart.shipping_fee - cart.discounted_shipping_fee}</span>
+                                                </div>
+                                            ) : (
+                                                <span className="text-red-500">暫不提供運送</span>
+                                            )}
                                         </div>
                                         {cart.discount > 0 && (
                                             <div className="flex justify-between items-center text-sm text-green-600">
@@ -408,7 +412,7 @@ export default function Checkout() {
                                         <Button
                                             type="button"
                                             onClick={handleStep2Next}
-                                            disabled={!form.formState.isValid && (!selectedStoreId || !form.getValues().name || !form.getValues().phone)}
+                                            disabled={!cart.shipping_available || (!form.formState.isValid && (!selectedStoreId || !form.getValues().name || !form.getValues().phone))}
                                         >
                                             下一步: 付款
                                         </Button>
@@ -16,7 +16,7 @@ import {
   PopoverTrigger,
 } from "@/components/ui/popover";
 import { useUser } from '@/hooks/useUser';
-import { callAPI } from '@/lib/api';
+import { apiURL, callAPI } from '@/lib/api';
 import { dict } from '@/lib/dict';
 import type { OrderItem } from '@/lib/types';
 import { cn } from "@/lib/utils";
@@ -341,12 +341,12 @@ const ClientInfo = () => {
                         <p className="text-xs font-semibold text-gray-500 mb-2">付款憑證:</p>
                         <div className="relative w-24 h-24 group">
                           <img
-                            src={`${import.meta.env.VITE_API_ROOT}${order.payment_proof}`}
+                            src={apiURL(order.payment_proof)}
                             alt="Payment Proof"
                             className="w-full h-full object-cover rounded-md border shadow-sm cursor-pointer transition-transform hover:scale-105"
                             onClick={(e) => {
                               e.stopPropagation();
-                              window.open(`${import.meta.env.VITE_API_ROOT}${order.payment_proof}`, '_blank');
+                              window.open(apiURL(order.payment_proof!), '_blank');
                             }}
                           />
                         </div>
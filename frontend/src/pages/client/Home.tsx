@@ -89,7 +89,7 @@ const Home = () => {
               <div
                 key={product.product_id}
                 className="hover:border-[black] border-[1px] cursor-pointer bg-[#FFF3E8] p-2 rounded-sm flex flex-col gap-2 items-center justify-center"
-                onClick={() => navigate(`/product/${product.product_id}`)}
+                onClick={() => navigate(`/product/${product.slug || product.product_id}`)}
               >
                 <img
                   src={product.images[0]?.thumbnail_url || product.images[0]?.url}
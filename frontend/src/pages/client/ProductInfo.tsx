@@ -37,6 +37,7 @@ import { ButtonGroup } from '@/components/ui/button-group';
 import type { SizeType } from '@/lib/types';
 
 const ProductInfo = () => {
+    // The route takes the product's slug, or its ID for products without one.
     const { productId } = useParams<{ productId: string }>();
     const navigate = useNavigate();
     const queryClient = useQueryClient();
@@ -44,7 +45,12 @@ const ProductInfo = () => {
 
     const { data: product, isLoading } = useQuery({
         queryKey: ['product', productId],
-        queryFn: () => productId ? callAPI('getProduct', { productId: parseInt(productId) }) : Promise.reject('No product ID'),
+        queryFn: () => {
+            if (!productId) return Promise.reject('No product ID');
+            return /^\d+$/.test(productId)
+                ? callAPI('getProduct', { productId: parseInt(productId) })
+                : callAPI('getProductBySlug', { slug: productId });
+        },
         enabled: !!productId,
     });
     const [selectedItem, setSelectedItem] = useState(0);
@@ -77,13 +83,13 @@ const ProductInfo = () => {
     });
 
     const handleAddToCart = () => {
-        if (!productId || !selectedSize) {
+        if (!product || !selectedSize) {
             toast.error('請選擇尺寸後再加入購物車。');
             return;
         }
 
         addToCartMutation.mutate({
-            product_id: parseInt(productId),
+            product_id: product.product_id,
             size_type: selectedSize,
             quantity: quantity,
         });